@@ -61,16 +61,20 @@ func NewLogLoop(opts LogLoopOptions) *LogLoop {
 // The return value of f is a boolean indicating if polling for new log lines should continue. Returning true will stop
 // this run with no error. Polling can be restarted by calling Run again.
 // Returning false will result in Run to continue polling for new log lines.
+//
+// Polling continues when the connection to the server broke, e.g. because the server restarted. Run returns the error
+// of ctx once it is done.
 func (l *LogLoop) Run(ctx context.Context, f func(l []StructuredLogLine) bool) error {
 	log := l.logger.With("action", "log-loop-run")
 	lines := make(chan []string)
-	errs := make(chan error)
 	l.lastSeen = nil
 	d := l.initialLogDuration
 	log.Info("initializing")
 
 	for {
 		select {
+		case <-ctx.Done():
+			return ctx.Err()
 		case line := <-lines:
 			pl := make([]StructuredLogLine, len(lines))
 			for _, s := range line {
@@ -94,10 +98,7 @@ func (l *LogLoop) Run(ctx context.Context, f func(l []StructuredLogLine) bool) e
 		case <-l.pollTicker.C:
 			err := l.p.WithConnection(ctx, func(c *rcon.Connection) error {
 				r, err := c.AdminLog(ctx, int32(d.Seconds()), "")
-				if err != nil {
-					log.Error("read", err)
-					errs <- err
-				} else {
+				if err == nil {
 					log.Debug("read", "no", len(r.Entries))
 					var logs []string
 					for _, entry := range r.Entries {
@@ -111,12 +112,11 @@ func (l *LogLoop) Run(ctx context.Context, f func(l []StructuredLogLine) bool) e
 				return err
 			})
 			if err != nil {
-				log.Error("init", err)
-				errs <- err
-			}
-		case err := <-errs:
-			if !rcon.IsBrokenHllConnection(err) {
-				return err
+				log.Error("read", "error", err)
+				// a broken connection was retired by the pool, the next poll uses another one
+				if !rcon.IsBrokenHllConnection(err) {
+					return err
+				}
 			}
 		}
 	}
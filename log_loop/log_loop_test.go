@@ -0,0 +1,76 @@
+package log_loop_test
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/floriansw/go-hll-rcon/log_loop"
+	"github.com/floriansw/go-hll-rcon/rconv2"
+	"github.com/floriansw/go-hll-rcon/rconv2/api"
+	"github.com/floriansw/go-hll-rcon/rconv2/rconv2test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LogLoop", func() {
+	var s *rconv2test.Server
+	var p *rconv2.ConnectionPool
+
+	BeforeEach(func() {
+		var err error
+		s, err = rconv2test.NewServer("secret")
+		Expect(err).ToNot(HaveOccurred())
+		p, err = rconv2.NewConnectionPool(rconv2.ConnectionPoolOptions{
+			Hostname: s.Host(),
+			Port:     s.Port(),
+			Password: "secret",
+		})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		_, _ = p.Shutdown(context.Background())
+		Expect(s.Close()).To(Succeed())
+	})
+
+	It("keeps polling when the server drops the connection", func() {
+		var polls atomic.Int32
+		s.Handle("GetAdminLog", func(r rconv2test.Request) rconv2test.Response {
+			if polls.Add(1) == 1 {
+				return rconv2test.Response{Disconnect: true}
+			}
+			return rconv2test.Response{Content: api.GetAdminLogResponse{
+				Entries: []api.AdminLogEntry{{Message: connected}},
+			}}
+		})
+		interval := 10 * time.Millisecond
+		l := log_loop.NewLogLoop(log_loop.LogLoopOptions{Pool: p, PollInterval: &interval})
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		var received []log_loop.StructuredLogLine
+		err := l.Run(ctx, func(lines []log_loop.StructuredLogLine) bool {
+			received = append(received, lines...)
+			return true
+		})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(polls.Load()).To(BeNumerically(">=", 2))
+		Expect(received).To(ContainElement(HaveField("Raw", connected)))
+	})
+
+	It("stops polling once the context is done", func() {
+		s.Respond("GetAdminLog", api.GetAdminLogResponse{})
+		interval := 10 * time.Millisecond
+		l := log_loop.NewLogLoop(log_loop.LogLoopOptions{Pool: p, PollInterval: &interval})
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		err := l.Run(ctx, func(lines []log_loop.StructuredLogLine) bool {
+			return false
+		})
+
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+	})
+})
@@ -0,0 +1,96 @@
+// Package rcon provides a Client for the commands supported by both, the legacy RCon v1 protocol (package rconv1) and
+// the RCon v2 protocol (package rconv2) of Hell Let Loose servers. It allows tools to manage servers regardless of the
+// protocol they expose, with the protocol chosen at configuration time.
+package rcon
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/floriansw/go-hll-rcon/rconv1"
+	"github.com/floriansw/go-hll-rcon/rconv2"
+)
+
+// ErrPlayerNotFound is returned when a command targets a player, who is not connected to the server. It is the same
+// error as rconv2.ErrPlayerNotFound.
+var ErrPlayerNotFound = rconv2.ErrPlayerNotFound
+
+type Version int
+
+const (
+	V1 Version = 1
+	V2 Version = 2
+)
+
+// Player is a player currently connected to the server.
+type Player struct {
+	Name string
+	// PlayerId is the Steam ID or the Windows (Microsoft) ID of the player.
+	PlayerId string
+}
+
+// Client sends commands to a Hell Let Loose server, independent of the RCon protocol version. Implementations are safe
+// for concurrent use.
+type Client interface {
+	// Players returns all players currently connected to the server.
+	Players(ctx context.Context) ([]Player, error)
+	KickPlayer(ctx context.Context, playerId, reason string) error
+	// TemporaryBanPlayer bans the player for the duration, which is rounded up to full hours.
+	TemporaryBanPlayer(ctx context.Context, playerId string, duration time.Duration, reason, adminName string) error
+	PermanentBanPlayer(ctx context.Context, playerId, reason, adminName string) error
+	// Broadcast sets the broadcast message shown to all players. An empty message removes the broadcast message.
+	Broadcast(ctx context.Context, message string) error
+	// ChangeMap immediately changes the map of the server.
+	ChangeMap(ctx context.Context, mapName string) error
+	// AdminLog returns the messages of the admin log of the given past duration, oldest first, e.g.
+	// "[355 ms (1743938197)] CONNECTED Player (76561198025480905)". The log_loop package can parse these messages.
+	AdminLog(ctx context.Context, since time.Duration) ([]string, error)
+	// Close closes all connections to the server.
+	Close() error
+}
+
+type Options struct {
+	// Version is the version of the RCon protocol the server is managed with.
+	Version Version
+	// Logger is an optional logging instance, passed to the rconv2.ConnectionPool.
+	Logger   *slog.Logger
+	Hostname string
+	Port     int
+	Password string
+}
+
+// New creates a Client for the RCon protocol version configured in the Options. RCon v1 Clients are connected to the
+// server immediately and reconnect with the next command once their connection was closed.
+func New(ctx context.Context, opts Options) (Client, error) {
+	switch opts.Version {
+	case V1:
+		c := &v1Client{opts: &rconv1.Options{
+			Hostname: opts.Hostname,
+			Port:     opts.Port,
+			Password: opts.Password,
+		}}
+		if _, err := c.conn(ctx); err != nil {
+			return nil, err
+		}
+		return c, nil
+	case V2:
+		p, err := rconv2.NewConnectionPool(rconv2.ConnectionPoolOptions{
+			Logger:   opts.Logger,
+			Hostname: opts.Hostname,
+			Port:     opts.Port,
+			Password: opts.Password,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return NewV2(p), nil
+	}
+	return nil, fmt.Errorf("unsupported RCon version %d", opts.Version)
+}
+
+// hours rounds the duration up to full hours, but at least one hour.
+func hours(d time.Duration) int32 {
+	return int32(max(1, (d+time.Hour-1)/time.Hour))
+}
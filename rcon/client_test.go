@@ -0,0 +1,130 @@
+package rcon_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/floriansw/go-hll-rcon/rcon"
+	"github.com/floriansw/go-hll-rcon/rconv1/rconv1test"
+	"github.com/floriansw/go-hll-rcon/rconv2/api"
+	"github.com/floriansw/go-hll-rcon/rconv2/rconv2test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	password = "secret"
+	playerId = "76561198000000001"
+)
+
+// fakeServer is the fake server of one of the RCon protocol versions, set up to respond to all commands of rcon.Client.
+type fakeServer struct {
+	host  string
+	port  int
+	close func() error
+	// sent returns the commands the server received, excluding the handshake
+	sent func() []string
+}
+
+func startV1() fakeServer {
+	s, err := rconv1test.NewServer(password)
+	Expect(err).ToNot(HaveOccurred())
+	s.Respond("get playerids", "1\tSome Player : "+playerId+"\t")
+	s.Respond("showlog", "[1:23 min (1606340677)] CONNECTED Some Player ("+playerId+")\n")
+	for _, c := range []string{"kick", "tempban", "permaban", "broadcast", "map"} {
+		s.Respond(c, rconv1test.Success)
+	}
+	return fakeServer{host: s.Host(), port: s.Port(), close: s.Close, sent: s.Commands}
+}
+
+func startV2() fakeServer {
+	s, err := rconv2test.NewServer(password)
+	Expect(err).ToNot(HaveOccurred())
+	s.Respond("GetServerInformation", api.GetPlayersResponse{Players: []api.GetPlayerResponse{{Id: playerId, Name: "Some Player"}}})
+	s.Respond("GetAdminLog", api.GetAdminLogResponse{Entries: []api.AdminLogEntry{{Message: "[1:23 min (1606340677)] CONNECTED Some Player (" + playerId + ")"}}})
+	s.Handle("KickPlayer", func(r rconv2test.Request) rconv2test.Response {
+		var k api.KickPlayer
+		if err := r.Bind(&k); err != nil || k.PlayerId != playerId {
+			return rconv2test.Response{StatusCode: 400, StatusMessage: "player not found"}
+		}
+		return rconv2test.Response{}
+	})
+	for _, c := range []string{"TemporaryBanPlayer", "PermanentBanPlayer", "ServerBroadcast", "ChangeMap"} {
+		s.Respond(c, "")
+	}
+	return fakeServer{host: s.Host(), port: s.Port(), close: s.Close, sent: func() []string {
+		var res []string
+		for _, r := range s.Requests() {
+			if r.Command != rconv2test.CommandServerConnect && r.Command != rconv2test.CommandLogin {
+				res = append(res, r.Command+" "+r.Body)
+			}
+		}
+		return res
+	}}
+}
+
+var _ = Describe("Client", func() {
+	for _, v := range []struct {
+		version rcon.Version
+		start   func() fakeServer
+	}{{rcon.V1, startV1}, {rcon.V2, startV2}} {
+		v := v
+		Context(fmt.Sprintf("with RCon v%d", v.version), func() {
+			var s fakeServer
+			var c rcon.Client
+			var ctx context.Context
+
+			BeforeEach(func() {
+				ctx = context.Background()
+				s = v.start()
+				var err error
+				c, err = rcon.New(ctx, rcon.Options{Version: v.version, Hostname: s.host, Port: s.port, Password: password})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				Expect(c.Close()).To(Succeed())
+				Expect(s.close()).To(Succeed())
+			})
+
+			It("lists players", func() {
+				p, err := c.Players(ctx)
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(p).To(Equal([]rcon.Player{{Name: "Some Player", PlayerId: playerId}}))
+			})
+
+			It("reads the admin log", func() {
+				l, err := c.AdminLog(ctx, time.Minute)
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(l).To(Equal([]string{"[1:23 min (1606340677)] CONNECTED Some Player (" + playerId + ")"}))
+			})
+
+			It("sends moderation commands", func() {
+				Expect(c.KickPlayer(ctx, playerId, "reason")).To(Succeed())
+				Expect(c.TemporaryBanPlayer(ctx, playerId, 90*time.Minute, "reason", "admin")).To(Succeed())
+				Expect(c.PermanentBanPlayer(ctx, playerId, "reason", "admin")).To(Succeed())
+				Expect(c.Broadcast(ctx, "message")).To(Succeed())
+				Expect(c.ChangeMap(ctx, "stmereeglise_warfare")).To(Succeed())
+
+				// RCon v1 resolves the name of the player to kick first
+				Expect(len(s.sent())).To(BeNumerically(">=", 5))
+			})
+
+			It("fails to kick players not connected to the server", func() {
+				err := c.KickPlayer(ctx, "unknown", "reason")
+
+				Expect(errors.Is(err, rcon.ErrPlayerNotFound)).To(BeTrue())
+			})
+		})
+	}
+
+	It("rejects unknown versions", func() {
+		_, err := rcon.New(context.Background(), rcon.Options{Version: 3, Hostname: "localhost", Port: 1})
+
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,12 @@
+package rcon_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestRcon(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "RCon Suite")
+}
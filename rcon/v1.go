@@ -0,0 +1,129 @@
+package rcon
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/floriansw/go-hll-rcon/rconv1"
+)
+
+// NewV1 creates a Client sending commands with the rconv1.Connection. The Client does not reconnect, once the
+// connection was closed, use New instead to create a reconnecting Client.
+func NewV1(c *rconv1.Connection) Client {
+	return &v1Client{c: c}
+}
+
+type v1Client struct {
+	// opts are used to reconnect, if not nil
+	opts *rconv1.Options
+
+	mu     sync.Mutex
+	c      *rconv1.Connection
+	closed bool
+}
+
+func (v *v1Client) conn(ctx context.Context) (*rconv1.Connection, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.closed {
+		return nil, rconv1.ErrConnectionClosed
+	}
+	if v.c != nil || v.opts == nil {
+		return v.c, nil
+	}
+	c, err := rconv1.Dial(ctx, *v.opts)
+	if err != nil {
+		return nil, err
+	}
+	v.c = c
+	return c, nil
+}
+
+// with runs f with the connection of the Client. The connection is dropped, if it was closed by f, so that the next
+// command reconnects.
+func (v *v1Client) with(ctx context.Context, f func(c *rconv1.Connection) error) error {
+	c, err := v.conn(ctx)
+	if err != nil {
+		return err
+	}
+	err = f(c)
+	if errors.Is(err, rconv1.ErrConnectionClosed) && v.opts != nil {
+		v.mu.Lock()
+		if v.c == c {
+			v.c = nil
+		}
+		v.mu.Unlock()
+	}
+	return err
+}
+
+func (v *v1Client) Players(ctx context.Context) (res []Player, err error) {
+	err = v.with(ctx, func(c *rconv1.Connection) error {
+		p, err := c.Players(ctx)
+		for _, e := range p {
+			res = append(res, Player{Name: e.Name, PlayerId: e.PlayerId})
+		}
+		return err
+	})
+	return
+}
+
+// KickPlayer resolves the name of the player first, as the RCon v1 protocol identifies the player to kick by name.
+func (v *v1Client) KickPlayer(ctx context.Context, playerId, reason string) error {
+	return v.with(ctx, func(c *rconv1.Connection) error {
+		players, err := c.Players(ctx)
+		if err != nil {
+			return err
+		}
+		for _, p := range players {
+			if p.PlayerId == playerId {
+				return c.KickPlayer(ctx, p.Name, reason)
+			}
+		}
+		return ErrPlayerNotFound
+	})
+}
+
+func (v *v1Client) TemporaryBanPlayer(ctx context.Context, playerId string, duration time.Duration, reason, adminName string) error {
+	return v.with(ctx, func(c *rconv1.Connection) error {
+		return c.TemporaryBanPlayer(ctx, playerId, time.Duration(hours(duration))*time.Hour, reason, adminName)
+	})
+}
+
+func (v *v1Client) PermanentBanPlayer(ctx context.Context, playerId, reason, adminName string) error {
+	return v.with(ctx, func(c *rconv1.Connection) error {
+		return c.PermanentBanPlayer(ctx, playerId, reason, adminName)
+	})
+}
+
+func (v *v1Client) Broadcast(ctx context.Context, message string) error {
+	return v.with(ctx, func(c *rconv1.Connection) error {
+		return c.Broadcast(ctx, message)
+	})
+}
+
+func (v *v1Client) ChangeMap(ctx context.Context, mapName string) error {
+	return v.with(ctx, func(c *rconv1.Connection) error {
+		return c.ChangeMap(ctx, mapName)
+	})
+}
+
+func (v *v1Client) AdminLog(ctx context.Context, since time.Duration) (res []string, err error) {
+	err = v.with(ctx, func(c *rconv1.Connection) error {
+		res, err = c.AdminLog(ctx, since)
+		return err
+	})
+	return
+}
+
+func (v *v1Client) Close() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.closed = true
+	if v.c == nil {
+		return nil
+	}
+	return v.c.Close()
+}
@@ -0,0 +1,97 @@
+package rcon
+
+import (
+	"context"
+	"time"
+
+	"github.com/floriansw/go-hll-rcon/rconv2"
+)
+
+// closeTimeout is the time Close waits for commands in progress to finish, before their connections are closed.
+const closeTimeout = 5 * time.Second
+
+// NewV2 creates a Client sending commands with Connections of the rconv2.ConnectionPool. Closing the Client shuts
+// down the pool.
+func NewV2(p *rconv2.ConnectionPool) Client {
+	return &v2Client{p: p}
+}
+
+type v2Client struct {
+	p *rconv2.ConnectionPool
+}
+
+// with runs f with a Connection of the pool, which is returned to the pool afterward.
+func (v *v2Client) with(ctx context.Context, f func(c *rconv2.Connection) error) error {
+	c, err := v.p.Get(ctx)
+	if err != nil {
+		return err
+	}
+	err = f(c)
+	v.p.Return(c, err)
+	return err
+}
+
+func (v *v2Client) Players(ctx context.Context) (res []Player, err error) {
+	err = v.with(ctx, func(c *rconv2.Connection) error {
+		p, err := c.Players(ctx)
+		if err != nil {
+			return err
+		}
+		for _, e := range p.Players {
+			res = append(res, Player{Name: e.Name, PlayerId: e.Id})
+		}
+		return nil
+	})
+	return
+}
+
+func (v *v2Client) KickPlayer(ctx context.Context, playerId, reason string) error {
+	return v.with(ctx, func(c *rconv2.Connection) error {
+		return c.KickPlayer(ctx, playerId, reason)
+	})
+}
+
+func (v *v2Client) TemporaryBanPlayer(ctx context.Context, playerId string, duration time.Duration, reason, adminName string) error {
+	return v.with(ctx, func(c *rconv2.Connection) error {
+		return c.TemporaryBanPlayer(ctx, playerId, hours(duration), reason, adminName)
+	})
+}
+
+func (v *v2Client) PermanentBanPlayer(ctx context.Context, playerId, reason, adminName string) error {
+	return v.with(ctx, func(c *rconv2.Connection) error {
+		return c.PermanentBanPlayer(ctx, playerId, reason, adminName)
+	})
+}
+
+func (v *v2Client) Broadcast(ctx context.Context, message string) error {
+	return v.with(ctx, func(c *rconv2.Connection) error {
+		return c.ServerBroadcast(ctx, message)
+	})
+}
+
+func (v *v2Client) ChangeMap(ctx context.Context, mapName string) error {
+	return v.with(ctx, func(c *rconv2.Connection) error {
+		return c.ChangeMap(ctx, mapName)
+	})
+}
+
+func (v *v2Client) AdminLog(ctx context.Context, since time.Duration) (res []string, err error) {
+	err = v.with(ctx, func(c *rconv2.Connection) error {
+		l, err := c.AdminLog(ctx, int32(since.Seconds()), "")
+		if err != nil {
+			return err
+		}
+		for _, e := range l.Entries {
+			res = append(res, e.Message)
+		}
+		return nil
+	})
+	return
+}
+
+func (v *v2Client) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+	_, err := v.p.Shutdown(ctx)
+	return err
+}
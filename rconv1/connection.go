@@ -0,0 +1,236 @@
+// Package rconv1 implements the legacy, text-based RCon protocol of Hell Let Loose servers. It is superseded by the
+// RCon v2 protocol implemented in the rconv2 package, which should be preferred for servers supporting it.
+package rconv1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Options struct {
+	// Hostname is the hostname/IP address of the Hell Let Loose server where it can be reached.
+	Hostname string
+	// Port is the Hell Let Loose RCon port of the server.
+	Port int
+	// Password is the RCon password of the Hell Let Loose server used to authenticate.
+	Password string
+	// Dial is an optional function used to open the TCP connection to the server, e.g. to connect through a proxy.
+	// If nil, a net.Dialer is used.
+	Dial DialFunc
+	// QuietPeriod is the time the server needs to stay silent after it sent parts of a response, before the response
+	// is considered complete. The protocol does not mark the end of a response, the QuietPeriod applies to responses,
+	// which end can not be detected by their content (e.g. logs and raw commands). Defaults to 200ms.
+	QuietPeriod time.Duration
+}
+
+// Connection is an authenticated connection to the RCon v1 endpoint of a Hell Let Loose server. The protocol does not
+// support concurrent commands, a Connection is safe for concurrent use, but sends commands one after another.
+//
+// A Connection is not usable anymore once a network error happened. Any further command fails with
+// ErrConnectionClosed, in which case a new Connection needs to be opened with Dial.
+type Connection struct {
+	socket *socket
+}
+
+// Dial opens a Connection to the server and authenticates with the password from the Options.
+func Dial(ctx context.Context, opts Options) (*Connection, error) {
+	if opts.Hostname == "" {
+		return nil, errors.New("hostname cannot be an empty string")
+	}
+	if opts.Port <= 0 || opts.Port > 65_536 {
+		return nil, errors.New("port must be a positive integer greater than 0 and lower than 65,536")
+	}
+	if opts.QuietPeriod <= 0 {
+		opts.QuietPeriod = defaultQuietPeriod
+	}
+	s, err := newSocket(ctx, opts.Dial, strings.TrimSuffix(strings.TrimPrefix(opts.Hostname, "["), "]"), opts.Port, opts.QuietPeriod)
+	if err != nil {
+		return nil, err
+	}
+	c := &Connection{socket: s}
+	if err := c.status(ctx, "Login", opts.Password); err != nil {
+		_ = s.Close()
+		if errors.Is(err, ErrCommandFailed) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close closes the connection to the server.
+func (c *Connection) Close() error {
+	return c.socket.Close()
+}
+
+// Command sends a raw command to the server and returns the response as is, e.g. Command(ctx, "get name").
+func (c *Connection) Command(ctx context.Context, command string) (string, error) {
+	return c.socket.exchange(ctx, command, nil)
+}
+
+// Players returns the name and the ID of all players currently connected to the server.
+func (c *Connection) Players(ctx context.Context) ([]Player, error) {
+	l, err := c.list(ctx, "get playerids")
+	if err != nil {
+		return nil, err
+	}
+	var res []Player
+	for _, e := range l {
+		i := strings.LastIndex(e, " : ")
+		if i == -1 {
+			return nil, fmt.Errorf("%w: player %s", ErrInvalidResponse, e)
+		}
+		res = append(res, Player{Name: e[:i], PlayerId: e[i+3:]})
+	}
+	return res, nil
+}
+
+// MapRotation returns the names of the maps in the current map rotation.
+func (c *Connection) MapRotation(ctx context.Context) ([]string, error) {
+	res, err := c.Command(ctx, "rotlist")
+	if err != nil {
+		return nil, err
+	}
+	return lines(res), nil
+}
+
+// AdminLog returns the log lines of the given past duration, oldest first, e.g. "[1:23 min (1606340677)] KILL: ...".
+// The server only accepts full minutes, the duration is rounded up accordingly.
+func (c *Connection) AdminLog(ctx context.Context, since time.Duration) ([]string, error) {
+	res, err := c.Command(ctx, fmt.Sprintf("showlog %d", minutes(since)))
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(res) == responseEmpty {
+		return nil, nil
+	}
+	var entries []string
+	for _, l := range lines(res) {
+		// messages (e.g. chat messages) might contain line breaks, each entry starts with its time in brackets
+		if len(entries) > 0 && !strings.HasPrefix(l, "[") {
+			entries[len(entries)-1] += "\n" + l
+			continue
+		}
+		entries = append(entries, l)
+	}
+	return entries, nil
+}
+
+// ChangeMap immediately changes the map of the server.
+func (c *Connection) ChangeMap(ctx context.Context, mapName string) error {
+	return c.status(ctx, "map", mapName)
+}
+
+// Broadcast sets the broadcast message shown to all players. An empty message removes the broadcast message.
+func (c *Connection) Broadcast(ctx context.Context, message string) error {
+	return c.status(ctx, "broadcast", message)
+}
+
+// MessagePlayer sends a message to the player with the given player ID.
+func (c *Connection) MessagePlayer(ctx context.Context, playerId, message string) error {
+	return c.status(ctx, "message", quote(playerId), message)
+}
+
+// KickPlayer kicks the player with the given name from the server. The RCon v1 protocol identifies the player to kick
+// by name, the ID of a player can be resolved with Players.
+func (c *Connection) KickPlayer(ctx context.Context, playerName, reason string) error {
+	return c.status(ctx, "kick", quote(playerName), quote(reason))
+}
+
+// PunishPlayer kills the player with the given name.
+func (c *Connection) PunishPlayer(ctx context.Context, playerName, reason string) error {
+	return c.status(ctx, "punish", quote(playerName), quote(reason))
+}
+
+// TemporaryBanPlayer bans the player with the given player ID for the duration, which is rounded up to full hours.
+func (c *Connection) TemporaryBanPlayer(ctx context.Context, playerId string, duration time.Duration, reason, adminName string) error {
+	return c.status(ctx, "tempban", quote(playerId), strconv.Itoa(hours(duration)), quote(reason), quote(adminName))
+}
+
+// PermanentBanPlayer bans the player with the given player ID permanently.
+func (c *Connection) PermanentBanPlayer(ctx context.Context, playerId, reason, adminName string) error {
+	return c.status(ctx, "permaban", quote(playerId), quote(reason), quote(adminName))
+}
+
+// status sends a command, which the server answers with either SUCCESS or FAIL.
+func (c *Connection) status(ctx context.Context, command string, args ...string) error {
+	cmd := strings.Join(append([]string{command}, args...), " ")
+	res, err := c.socket.exchange(ctx, cmd, func(res []byte) bool {
+		s := string(res)
+		return s == responseSuccess || s == responseFail
+	})
+	if err != nil {
+		return err
+	}
+	if res != responseSuccess {
+		return commandFailed(command)
+	}
+	return nil
+}
+
+// list sends a command, which the server answers with a tab-separated list prefixed by the number of entries.
+func (c *Connection) list(ctx context.Context, command string) ([]string, error) {
+	res, err := c.socket.exchange(ctx, command, func(res []byte) bool {
+		n, entries, err := parseList(string(res))
+		// each entry is terminated by a tab, otherwise the last entry might not be received completely yet
+		return err == nil && len(entries) >= n && (n == 0 || res[len(res)-1] == '\t')
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res == responseFail {
+		return nil, commandFailed(command)
+	}
+	n, entries, err := parseList(res)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) != n {
+		return nil, fmt.Errorf("%w: expected %d entries, got %d", ErrInvalidResponse, n, len(entries))
+	}
+	return entries, nil
+}
+
+// parseList parses a tab-separated list prefixed by the number of entries, e.g. "2\tfirst\tsecond\t". It returns the
+// number of entries announced by the server together with the entries received.
+func parseList(res string) (int, []string, error) {
+	parts := strings.Split(res, "\t")
+	n, err := strconv.Atoi(parts[0])
+	if err != nil || n < 0 {
+		return 0, nil, fmt.Errorf("%w: %s", ErrInvalidResponse, res)
+	}
+	var entries []string
+	for _, p := range parts[1:] {
+		if p != "" {
+			entries = append(entries, p)
+		}
+	}
+	return n, entries, nil
+}
+
+func lines(res string) []string {
+	var l []string
+	for _, s := range strings.Split(strings.ReplaceAll(res, "\r\n", "\n"), "\n") {
+		if s != "" {
+			l = append(l, s)
+		}
+	}
+	return l
+}
+
+// quote wraps a parameter of a command in quotes, so that it may contain spaces.
+func quote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `'`) + `"`
+}
+
+func minutes(d time.Duration) int {
+	return max(1, int((d+time.Minute-1)/time.Minute))
+}
+
+func hours(d time.Duration) int {
+	return max(1, int((d+time.Hour-1)/time.Hour))
+}
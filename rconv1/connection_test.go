@@ -0,0 +1,129 @@
+package rconv1_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/floriansw/go-hll-rcon/rconv1"
+	"github.com/floriansw/go-hll-rcon/rconv1/rconv1test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	password = "secret"
+)
+
+var _ = Describe("Connection", func() {
+	var s *rconv1test.Server
+	var ctx context.Context
+	var opts rconv1.Options
+	var conns []*rconv1.Connection
+
+	BeforeEach(func() {
+		var err error
+		s, err = rconv1test.NewServer(password)
+		Expect(err).ToNot(HaveOccurred())
+		ctx = context.Background()
+		opts = rconv1.Options{Hostname: s.Host(), Port: s.Port(), Password: password, QuietPeriod: 20 * time.Millisecond}
+	})
+
+	AfterEach(func() {
+		for _, c := range conns {
+			_ = c.Close()
+		}
+		conns = nil
+		Expect(s.Close()).To(Succeed())
+	})
+
+	dial := func() *rconv1.Connection {
+		c, err := rconv1.Dial(ctx, opts)
+		Expect(err).ToNot(HaveOccurred())
+		conns = append(conns, c)
+		return c
+	}
+
+	It("fails with wrong credentials", func() {
+		opts.Password = "wrong"
+
+		_, err := rconv1.Dial(ctx, opts)
+
+		Expect(errors.Is(err, rconv1.ErrInvalidCredentials)).To(BeTrue())
+	})
+
+	It("lists players", func() {
+		s.Respond("get playerids", "2\tFirst Player : 76561198000000001\tSecond : 76561198000000002\t")
+		c := dial()
+
+		p, err := c.Players(ctx)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p).To(Equal([]rconv1.Player{
+			{Name: "First Player", PlayerId: "76561198000000001"},
+			{Name: "Second", PlayerId: "76561198000000002"},
+		}))
+	})
+
+	It("lists no players on an empty server", func() {
+		s.Respond("get playerids", "0\t")
+		c := dial()
+
+		p, err := c.Players(ctx)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p).To(BeEmpty())
+	})
+
+	It("sends commands with quoted parameters", func() {
+		s.Respond("kick", rconv1test.Success)
+		c := dial()
+
+		Expect(c.KickPlayer(ctx, "Some Player", "being rude")).To(Succeed())
+
+		Expect(s.Commands()).To(Equal([]string{`kick "Some Player" "being rude"`}))
+	})
+
+	It("returns failed commands as error", func() {
+		c := dial()
+
+		err := c.ChangeMap(ctx, "unknown_map")
+
+		Expect(errors.Is(err, rconv1.ErrCommandFailed)).To(BeTrue())
+	})
+
+	It("reads long responses", func() {
+		lines := make([]string, 500)
+		for i := range lines {
+			lines[i] = "[1:23 min (1606340677)] CHAT[Allies][Player(Allies/76561198000000001)]: " + strings.Repeat("a", 50)
+		}
+		s.Respond("showlog", strings.Join(lines, "\n")+"\n")
+		c := dial()
+
+		l, err := c.AdminLog(ctx, 90*time.Second)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(l).To(HaveLen(500))
+		Expect(s.Commands()).To(Equal([]string{"showlog 2"}))
+	})
+
+	It("returns no log entries for an empty log", func() {
+		s.Respond("showlog", "EMPTY")
+		c := dial()
+
+		l, err := c.AdminLog(ctx, time.Minute)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(l).To(BeEmpty())
+	})
+
+	It("does not accept commands after the connection was closed", func() {
+		c := dial()
+		Expect(c.Close()).To(Succeed())
+
+		_, err := c.Command(ctx, "get name")
+
+		Expect(errors.Is(err, rconv1.ErrConnectionClosed)).To(BeTrue())
+	})
+})
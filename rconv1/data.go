@@ -0,0 +1,35 @@
+package rconv1
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	responseSuccess = "SUCCESS"
+	responseFail    = "FAIL"
+	responseEmpty   = "EMPTY"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("wrong password")
+	// ErrCommandFailed is returned when the server answered a command with FAIL, e.g. because the player to kick is
+	// not connected to the server.
+	ErrCommandFailed = errors.New("command failed")
+	// ErrConnectionClosed is returned by commands sent with a Connection, which was closed, either by calling Close or
+	// because of a previous network error. A new Connection needs to be opened with Dial.
+	ErrConnectionClosed = errors.New("connection closed")
+	// ErrInvalidResponse is returned when the response of the server could not be parsed.
+	ErrInvalidResponse = errors.New("invalid response")
+)
+
+// Player is a player currently connected to the server.
+type Player struct {
+	Name string
+	// PlayerId is the Steam ID or the Windows (Microsoft) ID of the player.
+	PlayerId string
+}
+
+func commandFailed(command string) error {
+	return fmt.Errorf("%w: %s", ErrCommandFailed, command)
+}
@@ -0,0 +1,176 @@
+package rconv1
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTimeout     = 20 * time.Second
+	defaultQuietPeriod = 200 * time.Millisecond
+	chunkSize          = 8192
+	maxKeySize         = 1024
+)
+
+// DialFunc opens a network connection to the address on the named network. It has the signature of
+// net.Dialer.DialContext.
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// socket is a TCP connection speaking the text-based RCon v1 protocol. Each command is written as a single XOR encoded
+// message, the server answers with an XOR encoded response without any framing. As the end of a response can not be
+// detected reliably, commands are sent one after another and the end of a response is either detected by its content
+// or by the server not sending any further data for a quiet period.
+type socket struct {
+	quiet time.Duration
+
+	mu     sync.Mutex
+	con    net.Conn
+	xorKey []byte
+	err    error
+}
+
+func newSocket(ctx context.Context, dial DialFunc, host string, port int, quiet time.Duration) (*socket, error) {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	con, err := dial(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+	s := &socket{con: con, quiet: quiet}
+	stop := s.watch(ctx)
+	defer stop()
+	// the server sends the XOR key right after the connection was established
+	_ = con.SetReadDeadline(deadline(ctx))
+	key := make([]byte, maxKeySize)
+	n, err := con.Read(key)
+	if err != nil {
+		_ = con.Close()
+		return nil, fmt.Errorf("read xor key: %w", err)
+	}
+	s.xorKey = key[:n]
+	return s, nil
+}
+
+// exchange sends the command to the server and returns the response. complete, if not nil, reports whether the
+// response received so far is complete. Otherwise, the response is considered complete once the server did not send
+// any further data for the quiet period of the socket.
+func (s *socket) exchange(ctx context.Context, command string, complete func(res []byte) bool) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return "", s.err
+	}
+	stop := s.watch(ctx)
+	defer stop()
+	res, err := s.roundTrip(ctx, command, complete)
+	if err != nil {
+		// the connection is in an unknown state, as parts of the response might still be received later on
+		s.close(fmt.Errorf("%w: %w", ErrConnectionClosed, err))
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("%w: %w", ctx.Err(), err)
+		}
+		return "", err
+	}
+	return res, nil
+}
+
+func (s *socket) roundTrip(ctx context.Context, command string, complete func(res []byte) bool) (string, error) {
+	d := deadline(ctx)
+	_ = s.con.SetWriteDeadline(d)
+	if _, err := s.con.Write(s.xor([]byte(command), 0)); err != nil {
+		return "", err
+	}
+
+	var res []byte
+	chunk := make([]byte, chunkSize)
+	for {
+		rd := d
+		if len(res) > 0 {
+			rd = minTime(d, time.Now().Add(s.quiet))
+		}
+		_ = s.con.SetReadDeadline(rd)
+		n, err := s.con.Read(chunk)
+		res = append(res, s.xor(chunk[:n], len(res))...)
+		if err != nil {
+			if len(res) > 0 && os.IsTimeout(err) && ctx.Err() == nil && time.Now().Before(d) {
+				return string(res), nil
+			}
+			return "", err
+		}
+		if complete != nil && complete(res) {
+			return string(res), nil
+		}
+	}
+}
+
+// watch interrupts any blocking read or write of the socket, once the context.Context is done. The returned function
+// needs to be called once the socket is not used with the context.Context anymore. The callback might still run after
+// stop returned, it must not touch the deadline once the next command owns the connection.
+func (s *socket) watch(ctx context.Context) func() {
+	var mu sync.Mutex
+	done := false
+	stop := context.AfterFunc(ctx, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if !done {
+			_ = s.con.SetDeadline(time.Unix(1, 0))
+		}
+	})
+	return func() {
+		stop()
+		mu.Lock()
+		done = true
+		mu.Unlock()
+	}
+}
+
+// xor encodes or decodes the message, which starts at the given offset of the whole message.
+func (s *socket) xor(msg []byte, offset int) []byte {
+	if len(s.xorKey) == 0 {
+		return msg
+	}
+	res := make([]byte, len(msg))
+	for i, b := range msg {
+		res[i] = b ^ s.xorKey[(offset+i)%len(s.xorKey)]
+	}
+	return res
+}
+
+func (s *socket) close(err error) {
+	if s.err == nil {
+		s.err = err
+		_ = s.con.Close()
+	}
+}
+
+func (s *socket) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil
+	}
+	s.err = ErrConnectionClosed
+	return s.con.Close()
+}
+
+// deadline returns the point in time until which an operation with the given context.Context is allowed to take.
+// Without a deadline in the context, the default timeout applies.
+func deadline(ctx context.Context) time.Time {
+	if d, ok := ctx.Deadline(); ok {
+		return d
+	}
+	return time.Now().Add(defaultTimeout)
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
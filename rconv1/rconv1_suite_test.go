@@ -0,0 +1,12 @@
+package rconv1_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestRconV1(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "RConV1 Suite")
+}
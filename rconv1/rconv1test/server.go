@@ -0,0 +1,176 @@
+// Package rconv1test provides an in-process fake of the legacy RCon v1 endpoint of a Hell Let Loose server. It speaks
+// the same text-based protocol as the game server (XOR encoding with a key sent on connect and the Login command),
+// which makes it possible to test code using the rconv1 package without a running game server.
+package rconv1test
+
+import (
+	"crypto/rand"
+	"net"
+	"strings"
+	"sync"
+)
+
+const (
+	Success = "SUCCESS"
+	Fail    = "FAIL"
+)
+
+// HandlerFunc answers a command the Server received with the response to send back. The command is passed as sent by
+// the client, e.g. `kick "Player" "reason"`.
+type HandlerFunc func(command string) string
+
+// Server is a fake Hell Let Loose RCon v1 server listening on a local TCP port. Handlers for commands can be registered
+// with Handle or Respond for a command name (case-insensitive), e.g. "kick" or "get playerids". A command is answered by
+// the handler with the longest matching name. A command without a handler is answered with FAIL, as well as any command
+// sent before a successful Login.
+type Server struct {
+	password string
+	l        net.Listener
+
+	mu       sync.Mutex
+	handlers map[string]HandlerFunc
+	commands []string
+	conns    map[net.Conn]bool
+	wg       sync.WaitGroup
+}
+
+// NewServer starts a Server on a random port of the loopback interface. Clients need to authenticate with the passed
+// password.
+func NewServer(password string) (*Server, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		password: password,
+		l:        l,
+		handlers: map[string]HandlerFunc{},
+		conns:    map[net.Conn]bool{},
+	}
+	s.wg.Add(1)
+	go s.accept()
+	return s, nil
+}
+
+// Host returns the IP address the Server is listening on.
+func (s *Server) Host() string {
+	return s.l.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port returns the TCP port the Server is listening on.
+func (s *Server) Port() int {
+	return s.l.Addr().(*net.TCPAddr).Port
+}
+
+// Handle registers the handler h for the command. A previously registered handler for the same command is replaced.
+func (s *Server) Handle(command string, h HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[strings.ToLower(command)] = h
+}
+
+// Respond registers a handler for the command, which answers each command with response.
+func (s *Server) Respond(command string, response string) {
+	s.Handle(command, func(string) string {
+		return response
+	})
+}
+
+// Commands returns all commands received by the Server so far, in the order they were received, excluding Login.
+func (s *Server) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.commands...)
+}
+
+// Close stops the Server and closes all open connections.
+func (s *Server) Close() error {
+	err := s.l.Close()
+	s.mu.Lock()
+	for c := range s.conns {
+		_ = c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) accept() {
+	defer s.wg.Done()
+	for {
+		con, err := s.l.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[con] = true
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.serve(con)
+	}
+}
+
+func (s *Server) serve(con net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		_ = con.Close()
+		s.mu.Lock()
+		delete(s.conns, con)
+		s.mu.Unlock()
+	}()
+	key := make([]byte, 4)
+	_, _ = rand.Read(key)
+	if _, err := con.Write(key); err != nil {
+		return
+	}
+	authenticated := false
+	buf := make([]byte, 8192)
+	for {
+		n, err := con.Read(buf)
+		if err != nil {
+			return
+		}
+		cmd := string(xor(buf[:n], key))
+		var res string
+		if name, password, _ := strings.Cut(cmd, " "); strings.EqualFold(name, "login") {
+			res = Fail
+			if password == s.password {
+				authenticated = true
+				res = Success
+			}
+		} else if !authenticated {
+			res = Fail
+		} else {
+			res = s.handle(cmd)
+		}
+		if _, err := con.Write(xor([]byte(res), key)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) handle(cmd string) string {
+	lc := strings.ToLower(cmd)
+	s.mu.Lock()
+	s.commands = append(s.commands, cmd)
+	var h HandlerFunc
+	var match string
+	for name, hf := range s.handlers {
+		if (lc == name || strings.HasPrefix(lc, name+" ")) && len(name) > len(match) {
+			h, match = hf, name
+		}
+	}
+	s.mu.Unlock()
+	if h == nil {
+		return Fail
+	}
+	return h(cmd)
+}
+
+func xor(msg, key []byte) []byte {
+	res := make([]byte, len(msg))
+	for i, b := range msg {
+		res[i] = b ^ key[i%len(key)]
+	}
+	return res
+}
@@ -5,3 +5,10 @@ type AddAdmin struct {
 	AdminGroup string `json:"AdminGroup"`
 	Comment    string `json:"Comment"`
 }
+
+func (a AddAdmin) Validate() error {
+	v := validator{}
+	v.notEmpty("PlayerId", a.PlayerId)
+	v.notEmpty("AdminGroup", a.AdminGroup)
+	return v.result("AddAdmin")
+}
@@ -3,3 +3,9 @@ package api
 type AddBannedWords struct {
 	BannedWords string `json:"BannedWords"`
 }
+
+func (a AddBannedWords) Validate() error {
+	v := validator{}
+	v.notEmpty("BannedWords", a.BannedWords)
+	return v.result("AddBannedWords")
+}
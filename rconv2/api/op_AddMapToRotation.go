@@ -4,3 +4,10 @@ type AddMapToRotation struct {
 	MapName string `json:"MapName"`
 	Index   int32  `json:"Index"`
 }
+
+func (a AddMapToRotation) Validate() error {
+	v := validator{}
+	v.notEmpty("MapName", a.MapName)
+	v.nonNegative("Index", a.Index)
+	return v.result("AddMapToRotation")
+}
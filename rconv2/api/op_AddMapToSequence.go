@@ -4,3 +4,10 @@ type AddMapToSequence struct {
 	MapName string `json:"MapName"`
 	Index   int32  `json:"Index"`
 }
+
+func (a AddMapToSequence) Validate() error {
+	v := validator{}
+	v.notEmpty("MapName", a.MapName)
+	v.nonNegative("Index", a.Index)
+	return v.result("AddMapToSequence")
+}
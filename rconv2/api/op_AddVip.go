@@ -4,3 +4,9 @@ type AddVip struct {
 	PlayerId string `json:"PlayerId"`
 	Comment  string `json:"Comment"`
 }
+
+func (a AddVip) Validate() error {
+	v := validator{}
+	v.notEmpty("PlayerId", a.PlayerId)
+	return v.result("AddVip")
+}
@@ -39,3 +39,9 @@ func (a AdminLogEntry) EventTime() time.Time {
 	}
 	return time.Unix(ts, 0)
 }
+
+func (g GetAdminLog) Validate() error {
+	v := validator{}
+	v.nonNegative("LogBackTrackTime", g.LogBackTrackTime)
+	return v.result("GetAdminLog")
+}
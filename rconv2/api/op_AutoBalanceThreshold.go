@@ -3,3 +3,9 @@ package api
 type SetAutoBalanceThreshold struct {
 	AutoBalanceThreshold int32 `json:"AutoBalanceThreshold"`
 }
+
+func (s SetAutoBalanceThreshold) Validate() error {
+	v := validator{}
+	v.nonNegative("AutoBalanceThreshold", s.AutoBalanceThreshold)
+	return v.result("SetAutoBalanceThreshold")
+}
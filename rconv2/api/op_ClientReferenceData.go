@@ -16,3 +16,9 @@ type Parameter struct {
 	DisplayMember string `json:"displayMember"`
 	ValueMember   string `json:"valueMember"`
 }
+
+func (g GetClientReferenceData) Validate() error {
+	v := validator{}
+	v.notEmpty("Command", string(g))
+	return v.result("GetClientReferenceData")
+}
@@ -5,3 +5,11 @@ type DisbandPlatoon struct {
 	SquadIndex int32  `json:"SquadIndex"`
 	Reason     string `json:"Reason"`
 }
+
+func (d DisbandPlatoon) Validate() error {
+	v := validator{}
+	v.nonNegative("TeamIndex", d.TeamIndex)
+	v.nonNegative("SquadIndex", d.SquadIndex)
+	v.maxLength("Reason", d.Reason, MaxReasonLength)
+	return v.result("DisbandPlatoon")
+}
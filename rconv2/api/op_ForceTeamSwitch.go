@@ -1,5 +1,7 @@
 package api
 
+import "fmt"
+
 type ForceMode uint8
 
 const (
@@ -11,3 +13,10 @@ type ForceTeamSwitch struct {
 	ForceMode ForceMode `json:"ForceMode"`
 	PlayerId  string    `json:"PlayerId"`
 }
+
+func (f ForceTeamSwitch) Validate() error {
+	v := validator{}
+	v.notEmpty("PlayerId", f.PlayerId)
+	v.check(f.ForceMode == ForceModeOnDeath || f.ForceMode == ForceModeImmediately, "ForceMode", fmt.Sprintf("must be a known force mode, got %d", f.ForceMode))
+	return v.result("ForceTeamSwitch")
+}
@@ -4,3 +4,10 @@ type KickPlayer struct {
 	Reason   string `json:"Reason"`
 	PlayerId string `json:"PlayerId"`
 }
+
+func (k KickPlayer) Validate() error {
+	v := validator{}
+	v.notEmpty("PlayerId", k.PlayerId)
+	v.maxLength("Reason", k.Reason, MaxReasonLength)
+	return v.result("KickPlayer")
+}
@@ -3,3 +3,9 @@ package api
 type ChangeMap struct {
 	MapName string `json:"mapName"`
 }
+
+func (c ChangeMap) Validate() error {
+	v := validator{}
+	v.notEmpty("MapName", c.MapName)
+	return v.result("ChangeMap")
+}
@@ -4,3 +4,10 @@ type MessagePlayer struct {
 	Message  string `json:"Message"`
 	PlayerId string `json:"PlayerId"`
 }
+
+func (m MessagePlayer) Validate() error {
+	v := validator{}
+	v.notEmpty("PlayerId", m.PlayerId)
+	v.message("Message", m.Message)
+	return v.result("MessagePlayer")
+}
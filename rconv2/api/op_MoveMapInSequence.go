@@ -4,3 +4,10 @@ type MoveMapInSequence struct {
 	CurrentIndex int32 `json:"CurrentIndex"`
 	NewIndex     int32 `json:"NewIndex"`
 }
+
+func (m MoveMapInSequence) Validate() error {
+	v := validator{}
+	v.nonNegative("CurrentIndex", m.CurrentIndex)
+	v.nonNegative("NewIndex", m.NewIndex)
+	return v.result("MoveMapInSequence")
+}
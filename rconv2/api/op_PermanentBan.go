@@ -5,3 +5,10 @@ type PermanentBanPlayer struct {
 	PlayerId  string `json:"PlayerId"`
 	AdminName string `json:"AdminName"`
 }
+
+func (p PermanentBanPlayer) Validate() error {
+	v := validator{}
+	v.notEmpty("PlayerId", p.PlayerId)
+	v.maxLength("Reason", p.Reason, MaxReasonLength)
+	return v.result("PermanentBanPlayer")
+}
@@ -4,3 +4,10 @@ type PunishPlayer struct {
 	Reason   string `json:"Reason"`
 	PlayerId string `json:"PlayerId"`
 }
+
+func (p PunishPlayer) Validate() error {
+	v := validator{}
+	v.notEmpty("PlayerId", p.PlayerId)
+	v.maxLength("Reason", p.Reason, MaxReasonLength)
+	return v.result("PunishPlayer")
+}
@@ -3,3 +3,9 @@ package api
 type RemoveAdmin struct {
 	PlayerId string `json:"playerId"`
 }
+
+func (r RemoveAdmin) Validate() error {
+	v := validator{}
+	v.notEmpty("PlayerId", r.PlayerId)
+	return v.result("RemoveAdmin")
+}
@@ -3,3 +3,9 @@ package api
 type RemoveBannedWords struct {
 	BannedWords string `json:"BannedWords"`
 }
+
+func (r RemoveBannedWords) Validate() error {
+	v := validator{}
+	v.notEmpty("BannedWords", r.BannedWords)
+	return v.result("RemoveBannedWords")
+}
@@ -3,3 +3,9 @@ package api
 type RemoveMapFromRotation struct {
 	Index int32 `json:"Index"`
 }
+
+func (r RemoveMapFromRotation) Validate() error {
+	v := validator{}
+	v.nonNegative("Index", r.Index)
+	return v.result("RemoveMapFromRotation")
+}
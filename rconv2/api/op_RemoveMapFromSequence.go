@@ -3,3 +3,9 @@ package api
 type RemoveMapFromSequence struct {
 	Index int32 `json:"Index"`
 }
+
+func (r RemoveMapFromSequence) Validate() error {
+	v := validator{}
+	v.nonNegative("Index", r.Index)
+	return v.result("RemoveMapFromSequence")
+}
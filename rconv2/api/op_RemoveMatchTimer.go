@@ -3,3 +3,9 @@ package api
 type RemoveMatchTimer struct {
 	GameMode GameMode `json:"GameMode"`
 }
+
+func (r RemoveMatchTimer) Validate() error {
+	v := validator{}
+	v.gameMode("GameMode", r.GameMode)
+	return v.result("RemoveMatchTimer")
+}
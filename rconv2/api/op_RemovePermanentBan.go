@@ -3,3 +3,9 @@ package api
 type RemovePermanentBan struct {
 	PlayerId string `json:"PlayerId"`
 }
+
+func (r RemovePermanentBan) Validate() error {
+	v := validator{}
+	v.notEmpty("PlayerId", r.PlayerId)
+	return v.result("RemovePermanentBan")
+}
@@ -4,3 +4,10 @@ type RemovePlayerFromPlatoon struct {
 	PlayerId string `json:"PlayerId"`
 	Reason   string `json:"Reason"`
 }
+
+func (r RemovePlayerFromPlatoon) Validate() error {
+	v := validator{}
+	v.notEmpty("PlayerId", r.PlayerId)
+	v.maxLength("Reason", r.Reason, MaxReasonLength)
+	return v.result("RemovePlayerFromPlatoon")
+}
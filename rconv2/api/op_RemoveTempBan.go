@@ -3,3 +3,9 @@ package api
 type RemoveTemporaryBan struct {
 	PlayerId string `json:"PlayerId"`
 }
+
+func (r RemoveTemporaryBan) Validate() error {
+	v := validator{}
+	v.notEmpty("PlayerId", r.PlayerId)
+	return v.result("RemoveTemporaryBan")
+}
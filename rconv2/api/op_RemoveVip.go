@@ -3,3 +3,9 @@ package api
 type RemoveVip struct {
 	PlayerId string `json:"PlayerId"`
 }
+
+func (r RemoveVip) Validate() error {
+	v := validator{}
+	v.notEmpty("PlayerId", r.PlayerId)
+	return v.result("RemoveVip")
+}
@@ -3,3 +3,9 @@ package api
 type RemoveWarmupTimer struct {
 	GameMode GameMode `json:"GameMode"`
 }
+
+func (r RemoveWarmupTimer) Validate() error {
+	v := validator{}
+	v.gameMode("GameMode", r.GameMode)
+	return v.result("RemoveWarmupTimer")
+}
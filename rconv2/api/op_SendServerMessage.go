@@ -3,3 +3,9 @@ package api
 type SendServerMessage struct {
 	Message string `json:"Message"`
 }
+
+func (s SendServerMessage) Validate() error {
+	v := validator{}
+	v.message("Message", s.Message)
+	return v.result("SendServerMessage")
+}
@@ -3,3 +3,10 @@ package api
 type ServerBroadcast struct {
 	Message string `json:"Message"`
 }
+
+func (s ServerBroadcast) Validate() error {
+	v := validator{}
+	// an empty message removes the current broadcast message
+	v.maxLength("Message", s.Message, MaxMessageLength)
+	return v.result("ServerBroadcast")
+}
@@ -55,6 +55,14 @@ var (
 	requiresValue = []ServerInformationName{
 		ServerInformationNamePlayer,
 	}
+	informationNames = []ServerInformationName{
+		ServerInformationNamePlayers,
+		ServerInformationNamePlayer,
+		ServerInformationNameMapRotation,
+		ServerInformationNameMapSequence,
+		ServerInformationNameSession,
+		ServerInformationNameServerConfig,
+	}
 )
 
 type GetServerInformation struct {
@@ -63,10 +71,10 @@ type GetServerInformation struct {
 }
 
 func (s GetServerInformation) Validate() error {
-	if slices.Contains(requiresValue, s.Name) && s.Value == "" {
-		return fmt.Errorf("%s command requires a Value", s.Name)
-	}
-	return nil
+	v := validator{}
+	v.check(slices.Contains(informationNames, s.Name), "Name", fmt.Sprintf("must be a known server information name, got %q", s.Name))
+	v.check(!slices.Contains(requiresValue, s.Name) || s.Value != "", "Value", fmt.Sprintf("is required for %s", s.Name))
+	return v.result("GetServerInformation")
 }
 
 type GetPlayersResponse struct {
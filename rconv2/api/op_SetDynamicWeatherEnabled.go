@@ -4,3 +4,9 @@ type SetDynamicWeatherEnabled struct {
 	MapId  string `json:"MapId"`
 	Enable bool   `json:"Enable"`
 }
+
+func (s SetDynamicWeatherEnabled) Validate() error {
+	v := validator{}
+	v.notEmpty("MapId", s.MapId)
+	return v.result("SetDynamicWeatherEnabled")
+}
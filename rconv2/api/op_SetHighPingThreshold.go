@@ -3,3 +3,9 @@ package api
 type SetHighPingThreshold struct {
 	HighPingThresholdMs int32 `json:"HighPingThresholdMs"`
 }
+
+func (s SetHighPingThreshold) Validate() error {
+	v := validator{}
+	v.nonNegative("HighPingThresholdMs", s.HighPingThresholdMs)
+	return v.result("SetHighPingThreshold")
+}
@@ -3,3 +3,9 @@ package api
 type SetIdleKickDuration struct {
 	IdleTimeoutMinutes int32 `json:"IdleTimeoutMinutes"`
 }
+
+func (s SetIdleKickDuration) Validate() error {
+	v := validator{}
+	v.nonNegative("IdleTimeoutMinutes", s.IdleTimeoutMinutes)
+	return v.result("SetIdleKickDuration")
+}
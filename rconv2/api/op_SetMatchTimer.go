@@ -4,3 +4,10 @@ type SetMatchTimer struct {
 	GameMode    GameMode `json:"GameMode"`
 	MatchLength int32    `json:"MatchLength"`
 }
+
+func (s SetMatchTimer) Validate() error {
+	v := validator{}
+	v.gameMode("GameMode", s.GameMode)
+	v.nonNegative("MatchLength", s.MatchLength)
+	return v.result("SetMatchTimer")
+}
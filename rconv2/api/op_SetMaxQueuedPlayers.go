@@ -3,3 +3,9 @@ package api
 type SetMaxQueuedPlayers struct {
 	MaxQueuedPlayers int32 `json:"MaxQueuedPlayers"`
 }
+
+func (s SetMaxQueuedPlayers) Validate() error {
+	v := validator{}
+	v.nonNegative("MaxQueuedPlayers", s.MaxQueuedPlayers)
+	return v.result("SetMaxQueuedPlayers")
+}
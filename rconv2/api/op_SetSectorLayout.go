@@ -1,9 +1,19 @@
 package api
 
 type SetSectorLayout struct {
-	SectorOne   string `json:"Sector_1"`
-	SectorTwo   string `json:"Sector_2"`
-	SectorThree string `json:"Sector_3"`
-	SectorFour  string `json:"Sector_4"`
-	SectorFive  string `json:"Sector_5"`
+	SectorOne   string `json:"SectorOne"`
+	SectorTwo   string `json:"SectorTwo"`
+	SectorThree string `json:"SectorThree"`
+	SectorFour  string `json:"SectorFour"`
+	SectorFive  string `json:"SectorFive"`
+}
+
+func (s SetSectorLayout) Validate() error {
+	v := validator{}
+	v.notEmpty("SectorOne", s.SectorOne)
+	v.notEmpty("SectorTwo", s.SectorTwo)
+	v.notEmpty("SectorThree", s.SectorThree)
+	v.notEmpty("SectorFour", s.SectorFour)
+	v.notEmpty("SectorFive", s.SectorFive)
+	return v.result("SetSectorLayout")
 }
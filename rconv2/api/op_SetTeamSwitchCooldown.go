@@ -3,3 +3,9 @@ package api
 type SetTeamSwitchCooldown struct {
 	TeamSwitchTimer int32 `json:"TeamSwitchTimer"`
 }
+
+func (s SetTeamSwitchCooldown) Validate() error {
+	v := validator{}
+	v.nonNegative("TeamSwitchTimer", s.TeamSwitchTimer)
+	return v.result("SetTeamSwitchCooldown")
+}
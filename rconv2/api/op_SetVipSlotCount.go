@@ -3,3 +3,9 @@ package api
 type SetVipSlotCount struct {
 	VipSlotCount int32 `json:"VipSlotCount"`
 }
+
+func (s SetVipSlotCount) Validate() error {
+	v := validator{}
+	v.nonNegative("VipSlotCount", s.VipSlotCount)
+	return v.result("SetVipSlotCount")
+}
@@ -4,3 +4,10 @@ type SetWarmupTimer struct {
 	GameMode     GameMode `json:"GameMode"`
 	WarmupLength int32    `json:"WarmupLength"`
 }
+
+func (s SetWarmupTimer) Validate() error {
+	v := validator{}
+	v.gameMode("GameMode", s.GameMode)
+	v.nonNegative("WarmupLength", s.WarmupLength)
+	return v.result("SetWarmupTimer")
+}
@@ -3,3 +3,9 @@ package api
 type SetWelcomeMessage struct {
 	Message string `json:"Message"`
 }
+
+func (s SetWelcomeMessage) Validate() error {
+	v := validator{}
+	v.maxLength("Message", s.Message, MaxMessageLength)
+	return v.result("SetWelcomeMessage")
+}
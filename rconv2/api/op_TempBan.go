@@ -6,3 +6,11 @@ type TemporaryBanPlayer struct {
 	Duration  int32  `json:"Duration"`
 	AdminName string `json:"AdminName"`
 }
+
+func (t TemporaryBanPlayer) Validate() error {
+	v := validator{}
+	v.notEmpty("PlayerId", t.PlayerId)
+	v.nonNegative("Duration", t.Duration)
+	v.maxLength("Reason", t.Reason, MaxReasonLength)
+	return v.result("TemporaryBanPlayer")
+}
@@ -3,3 +3,9 @@ package api
 type SetVoteKickThreshold struct {
 	ThresholdValue string `json:"ThresholdValue"`
 }
+
+func (s SetVoteKickThreshold) Validate() error {
+	v := validator{}
+	v.check(validVoteKickThreshold(s.ThresholdValue), "ThresholdValue", "must be a comma-separated list of pairs of player count and threshold, e.g. 0,1,10,5")
+	return v.result("SetVoteKickThreshold")
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxMessageLength is the maximum length of messages sent to players or the server (e.g. MessagePlayer,
+	// ServerBroadcast or SetWelcomeMessage), as validated before the command is sent.
+	MaxMessageLength = 1000
+	// MaxReasonLength is the maximum length of reasons given for moderation actions (e.g. KickPlayer or
+	// TemporaryBanPlayer), as validated before the command is sent.
+	MaxReasonLength = 500
+)
+
+// FieldError describes why the value of a field of a request is invalid.
+type FieldError struct {
+	// Field is the name of the field in the request struct, e.g. PlayerId.
+	Field   string
+	Message string
+}
+
+func (f FieldError) Error() string {
+	return fmt.Sprintf("%s %s", f.Field, f.Message)
+}
+
+// ValidationError is returned by the Validate method of requests with invalid values. It lists all invalid fields of
+// the request, not only the first one.
+type ValidationError struct {
+	Command string
+	Fields  []FieldError
+}
+
+func (v *ValidationError) Error() string {
+	var s []string
+	for _, f := range v.Fields {
+		s = append(s, f.Error())
+	}
+	return fmt.Sprintf("invalid %s request: %s", v.Command, strings.Join(s, ", "))
+}
+
+// Valid reports whether g is one of the known game modes.
+func (g GameMode) Valid() bool {
+	switch g {
+	case GameModeWarfare, GameModeOffensive, GameModeSkirmish, GameModeConquest:
+		return true
+	}
+	return false
+}
+
+// validator collects the FieldError of all invalid fields of a request.
+type validator struct {
+	fields []FieldError
+}
+
+func (v *validator) check(ok bool, field, message string) {
+	if !ok {
+		v.fields = append(v.fields, FieldError{Field: field, Message: message})
+	}
+}
+
+func (v *validator) notEmpty(field, value string) {
+	v.check(strings.TrimSpace(value) != "", field, "must not be empty")
+}
+
+func (v *validator) maxLength(field, value string, max int) {
+	v.check(len([]rune(value)) <= max, field, fmt.Sprintf("must not be longer than %d characters", max))
+}
+
+func (v *validator) nonNegative(field string, value int32) {
+	v.check(value >= 0, field, "must not be negative")
+}
+
+func (v *validator) gameMode(field string, value GameMode) {
+	v.check(value.Valid(), field, fmt.Sprintf("must be a known game mode, got %q", value))
+}
+
+func (v *validator) message(field, value string) {
+	v.notEmpty(field, value)
+	v.maxLength(field, value, MaxMessageLength)
+}
+
+// result returns a ValidationError with all collected fields, or nil, if all fields are valid.
+func (v *validator) result(command string) error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{
+		Command: command,
+		Fields:  v.fields,
+	}
+}
+
+// validVoteKickThreshold reports whether the threshold is a comma-separated list of pairs of a player count and the
+// number of votes required from that player count onward, e.g. "0,1,10,5".
+func validVoteKickThreshold(threshold string) bool {
+	parts := strings.Split(threshold, ",")
+	if len(parts)%2 != 0 {
+		return false
+	}
+	for _, p := range parts {
+		if n, err := strconv.Atoi(strings.TrimSpace(p)); err != nil || n < 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package api_test
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/floriansw/go-hll-rcon/rconv2/api"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Validation", func() {
+	It("accepts valid requests", func() {
+		Expect(api.KickPlayer{PlayerId: "76561198025480905", Reason: "reason"}.Validate()).To(Succeed())
+		Expect(api.SetMatchTimer{GameMode: api.GameModeWarfare, MatchLength: 90}.Validate()).To(Succeed())
+		Expect(api.SetVoteKickThreshold{ThresholdValue: "0,1,10,5"}.Validate()).To(Succeed())
+		Expect(api.GetServerInformation{Name: api.ServerInformationNamePlayers}.Validate()).To(Succeed())
+	})
+
+	It("lists all invalid fields", func() {
+		err := api.TemporaryBanPlayer{PlayerId: " ", Duration: -1, Reason: strings.Repeat("a", api.MaxReasonLength+1)}.Validate()
+
+		var verr *api.ValidationError
+		Expect(errors.As(err, &verr)).To(BeTrue())
+		Expect(verr.Command).To(Equal("TemporaryBanPlayer"))
+		Expect(verr.Fields).To(HaveLen(3))
+		Expect(verr.Fields[0].Field).To(Equal("PlayerId"))
+		Expect(verr.Fields[1].Field).To(Equal("Duration"))
+		Expect(verr.Fields[2].Field).To(Equal("Reason"))
+	})
+
+	It("rejects unknown enum values", func() {
+		Expect(api.SetWarmupTimer{GameMode: "Deathmatch", WarmupLength: 3}.Validate()).To(HaveOccurred())
+		Expect(api.ForceTeamSwitch{PlayerId: "1", ForceMode: 5}.Validate()).To(HaveOccurred())
+		Expect(api.GetServerInformation{Name: "unknown"}.Validate()).To(HaveOccurred())
+	})
+
+	It("rejects malformed vote kick thresholds", func() {
+		Expect(api.SetVoteKickThreshold{ThresholdValue: ""}.Validate()).To(HaveOccurred())
+		Expect(api.SetVoteKickThreshold{ThresholdValue: "0,1,10"}.Validate()).To(HaveOccurred())
+		Expect(api.SetVoteKickThreshold{ThresholdValue: "0,a"}.Validate()).To(HaveOccurred())
+	})
+
+	It("requires all sectors of a sector layout", func() {
+		Expect(api.SetSectorLayout{
+			SectorOne: "a", SectorTwo: "b", SectorThree: "c", SectorFour: "d", SectorFive: "e",
+		}.Validate()).To(Succeed())
+
+		err := api.SetSectorLayout{SectorOne: "a", SectorTwo: "b", SectorThree: "c", SectorFour: "d"}.Validate()
+
+		var verr *api.ValidationError
+		Expect(errors.As(err, &verr)).To(BeTrue())
+		Expect(verr.Fields).To(HaveLen(1))
+		Expect(verr.Fields[0].Field).To(Equal("SectorFive"))
+	})
+
+	It("requires a value for player information", func() {
+		Expect(api.GetServerInformation{Name: api.ServerInformationNamePlayer}.Validate()).To(HaveOccurred())
+	})
+})
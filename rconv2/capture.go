@@ -0,0 +1,103 @@
+package rconv2
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	redacted = "[redacted]"
+)
+
+// CaptureEntry is a single request sent to the server together with the response received for it, as written by a
+// Recorder. Secrets, like the password, the auth token and the XOR key, are not part of a CaptureEntry.
+type CaptureEntry struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	// Request is the ContentBody of the request.
+	Request       string `json:"request"`
+	StatusCode    int    `json:"statusCode"`
+	StatusMessage string `json:"statusMessage"`
+	// Response is the contentBody of the response.
+	Response string        `json:"response"`
+	Duration time.Duration `json:"duration"`
+	// Error is set, if no response was received for the request.
+	Error string `json:"error,omitempty"`
+}
+
+// Recorder writes each request and response exchanged with the server as a CaptureEntry to a JSON Lines (JSONL)
+// capture. It can be shared between Connections and is safe for concurrent use. A capture can be read with ReadCapture
+// and served back to a client with the replay server of the rconv2test package.
+type Recorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewRecorder creates a Recorder writing the capture to w. The caller is responsible to close w, if needed, once
+// the Recorder is not used anymore.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{
+		enc: json.NewEncoder(w),
+	}
+}
+
+// ReadCapture reads all entries of a capture written by a Recorder.
+func ReadCapture(r io.Reader) ([]CaptureEntry, error) {
+	var res []CaptureEntry
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for s.Scan() {
+		if len(s.Bytes()) == 0 {
+			continue
+		}
+		var e CaptureEntry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("read capture entry %d: %w", len(res)+1, err)
+		}
+		res = append(res, e)
+	}
+	return res, s.Err()
+}
+
+// record writes the exchange of req and res to the capture. A nil Recorder does nothing.
+func (r *Recorder) record(req rawRequest, res []byte, started time.Time, err error) {
+	if r == nil {
+		return
+	}
+	e := CaptureEntry{
+		Time:     started,
+		Command:  req.Command,
+		Duration: time.Since(started),
+	}
+	if b, ok := req.Body.(string); ok {
+		e.Request = b
+	} else if req.Body != nil {
+		d, _ := json.Marshal(req.Body)
+		e.Request = string(d)
+	}
+	if err != nil {
+		e.Error = err.Error()
+	} else {
+		var data Response[string]
+		_ = json.Unmarshal(res, &data)
+		e.StatusCode = data.StatusCode
+		e.StatusMessage = data.StatusMessage
+		e.Response = data.Content
+	}
+	switch e.Command {
+	case "Login":
+		// the request carries the password, the response the auth token
+		e.Request = redacted
+		e.Response = redacted
+	case "ServerConnect":
+		e.Response = redacted
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_ = r.enc.Encode(e)
+}
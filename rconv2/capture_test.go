@@ -0,0 +1,66 @@
+package rconv2_test
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/floriansw/go-hll-rcon/rconv2"
+	"github.com/floriansw/go-hll-rcon/rconv2/api"
+	"github.com/floriansw/go-hll-rcon/rconv2/rconv2test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Recorder", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("records exchanges without secrets and replays them", func() {
+		s, err := rconv2test.NewServer(password)
+		Expect(err).ToNot(HaveOccurred())
+		defer s.Close()
+		s.Respond("GetServerInformation", api.GetSessionResponse{MapName: "CARENTAN", PlayerCount: 42})
+		s.Handle("KickPlayer", func(r rconv2test.Request) rconv2test.Response {
+			return rconv2test.Response{StatusCode: 400, StatusMessage: "player not found"}
+		})
+		var capture bytes.Buffer
+		p := newPool(s, rconv2.ConnectionPoolOptions{Recorder: rconv2.NewRecorder(&capture)})
+		err = p.WithConnection(ctx, func(c *rconv2.Connection) error {
+			_, err := c.SessionInfo(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(c.KickPlayer(ctx, "1", "reason")).ToNot(Succeed())
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+		p.Shutdown(context.Background())
+
+		Expect(capture.String()).ToNot(ContainSubstring(password))
+		for _, r := range s.Requests() {
+			if r.AuthToken != "" {
+				Expect(capture.String()).ToNot(ContainSubstring(r.AuthToken))
+			}
+		}
+		entries, err := rconv2.ReadCapture(bytes.NewReader(capture.Bytes()))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(4))
+		Expect(entries[2].Command).To(Equal("GetServerInformation"))
+		Expect(entries[3].StatusCode).To(Equal(400))
+
+		replay, err := rconv2test.NewReplayServer("other", bytes.NewReader(capture.Bytes()))
+		Expect(err).ToNot(HaveOccurred())
+		defer replay.Close()
+		p = newPool(replay, rconv2.ConnectionPoolOptions{Password: "other"})
+		defer p.Shutdown(context.Background())
+		err = p.WithConnection(ctx, func(c *rconv2.Connection) error {
+			res, err := c.SessionInfo(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.PlayerCount).To(Equal(42))
+			Expect(c.KickPlayer(ctx, "1", "reason")).To(MatchError(ContainSubstring("player not found")))
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
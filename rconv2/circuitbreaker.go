@@ -0,0 +1,254 @@
+package rconv2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen matches CircuitOpen errors, returned when no connection to the server was attempted, because the
+// circuit breaker of the ConnectionPool is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+var defaultCircuitBreakerPolicy = CircuitBreakerPolicy{
+	FailureThreshold: 5,
+	InitialBackoff:   5 * time.Second,
+	MaxBackoff:       time.Minute,
+	Multiplier:       2,
+}
+
+// CircuitState is the state of the circuit breaker of a ConnectionPool.
+type CircuitState int
+
+const (
+	// CircuitStateClosed is the normal state, in which connections to the server are opened as needed.
+	CircuitStateClosed CircuitState = iota
+	// CircuitStateOpen is the state after too many consecutive connection failures. Opening a connection fails with a
+	// CircuitOpen error without contacting the server.
+	CircuitStateOpen
+	// CircuitStateHalfOpen is the state while a single probe connection is opened to check if the server is reachable
+	// again. Opening other connections still fails with a CircuitOpen error.
+	CircuitStateHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitStateClosed:
+		return "closed"
+	case CircuitStateOpen:
+		return "open"
+	case CircuitStateHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitBreakerPolicy configures the circuit breaker of a ConnectionPool. Once FailureThreshold connections to the
+// server failed in a row, the circuit breaker opens and Get fails fast instead of trying to connect to a server, which
+// is most likely down. While the circuit breaker is open, a probe connection is attempted in the background after
+// InitialBackoff, and after a growing delay for each further failed probe. The circuit breaker closes once a probe
+// succeeded. Zero values are replaced with their respective default.
+type CircuitBreakerPolicy struct {
+	// FailureThreshold is the number of consecutive connection failures, which open the circuit breaker. Defaults
+	// to 5.
+	FailureThreshold int
+	// InitialBackoff is the delay before the first probe after the circuit breaker opened. Defaults to 5s.
+	InitialBackoff time.Duration
+	// MaxBackoff is the upper limit of the delay between two probes. Defaults to 1m.
+	MaxBackoff time.Duration
+	// Multiplier is the factor the delay grows with after each failed probe. Defaults to 2.
+	Multiplier float64
+	// OnStateChange is an optional callback, which is called whenever the state of the circuit breaker changed, e.g.
+	// to alert when the server is unreachable. It is called without holding any lock of the pool, and must not block.
+	OnStateChange func(from, to CircuitState)
+}
+
+func (c *CircuitBreakerPolicy) withDefaults() CircuitBreakerPolicy {
+	p := *c
+	if p.FailureThreshold <= 0 {
+		p.FailureThreshold = defaultCircuitBreakerPolicy.FailureThreshold
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = defaultCircuitBreakerPolicy.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaultCircuitBreakerPolicy.MaxBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = defaultCircuitBreakerPolicy.Multiplier
+	}
+	return p
+}
+
+// backoff returns the delay before the probe with the given number, starting at 0 for the first probe.
+func (c CircuitBreakerPolicy) backoff(probe int) time.Duration {
+	d := float64(c.InitialBackoff) * math.Pow(c.Multiplier, float64(probe))
+	return time.Duration(min(d, float64(c.MaxBackoff)))
+}
+
+// CircuitOpen is returned when a connection was not opened, because the circuit breaker of the ConnectionPool is
+// open. It matches ErrCircuitOpen with errors.Is and wraps the error of the last failed connection attempt.
+type CircuitOpen struct {
+	failures int
+	retryAt  time.Time
+	err      error
+}
+
+func (e CircuitOpen) Error() string {
+	return fmt.Sprintf("%s after %d failed connection attempts, next attempt in %s: %s", ErrCircuitOpen, e.failures, e.RetryAfter().Round(time.Millisecond), e.err)
+}
+
+// Failures returns the number of consecutive failed connection attempts, including failed probes.
+func (e CircuitOpen) Failures() int {
+	return e.failures
+}
+
+// RetryAfter returns the time until the next probe is attempted. It is 0 while a probe is in progress.
+func (e CircuitOpen) RetryAfter() time.Duration {
+	return max(0, time.Until(e.retryAt))
+}
+
+func (e CircuitOpen) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+func (e CircuitOpen) Unwrap() error {
+	return e.err
+}
+
+// circuitBreaker implements the CircuitBreakerPolicy. A nil circuitBreaker is always closed.
+type circuitBreaker struct {
+	policy CircuitBreakerPolicy
+	// probe attempts a single connection to the server
+	probe func(ctx context.Context) error
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	probes   int
+	lastErr  error
+	retryAt  time.Time
+	timer    *time.Timer
+	stopped  bool
+}
+
+func newCircuitBreaker(policy *CircuitBreakerPolicy, probe func(ctx context.Context) error) *circuitBreaker {
+	if policy == nil {
+		return nil
+	}
+	return &circuitBreaker{policy: policy.withDefaults(), probe: probe}
+}
+
+// current returns the current state of the circuit breaker.
+func (b *circuitBreaker) current() CircuitState {
+	if b == nil {
+		return CircuitStateClosed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// allow returns a CircuitOpen error, if no connection may be attempted.
+func (b *circuitBreaker) allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitStateClosed {
+		return nil
+	}
+	return CircuitOpen{failures: b.failures, retryAt: b.retryAt, err: b.lastErr}
+}
+
+// record records the result of a connection attempt. Attempts aborted by the caller, because its context.Context was
+// cancelled or its deadline exceeded (ErrCommandAborted), are ignored, as they do not tell anything about the server,
+// unless a connection attempt failed before the caller gave up.
+func (b *circuitBreaker) record(err error) {
+	if b == nil || (errors.Is(err, ErrCommandAborted) && !errors.As(err, new(connectAborted))) {
+		return
+	}
+	b.mu.Lock()
+	if err == nil {
+		b.failures = 0
+		b.mu.Unlock()
+		return
+	}
+	b.failures++
+	b.lastErr = err
+	if b.state != CircuitStateClosed || b.failures < b.policy.FailureThreshold || b.stopped {
+		b.mu.Unlock()
+		return
+	}
+	b.probes = 0
+	b.schedule()
+	b.mu.Unlock()
+	b.notify(CircuitStateClosed, CircuitStateOpen)
+}
+
+// schedule opens the circuit breaker and schedules the next probe. b.mu must be held.
+func (b *circuitBreaker) schedule() {
+	d := b.policy.backoff(b.probes)
+	b.state = CircuitStateOpen
+	b.retryAt = time.Now().Add(d)
+	b.timer = time.AfterFunc(d, b.runProbe)
+}
+
+func (b *circuitBreaker) runProbe() {
+	b.mu.Lock()
+	if b.stopped {
+		b.mu.Unlock()
+		return
+	}
+	b.state = CircuitStateHalfOpen
+	b.retryAt = time.Time{}
+	b.mu.Unlock()
+	b.notify(CircuitStateOpen, CircuitStateHalfOpen)
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	err := b.probe(ctx)
+	cancel()
+
+	b.mu.Lock()
+	if b.stopped {
+		b.mu.Unlock()
+		return
+	}
+	to := CircuitStateClosed
+	if err != nil {
+		b.failures++
+		b.lastErr = err
+		b.probes++
+		b.schedule()
+		to = CircuitStateOpen
+	} else {
+		b.state = CircuitStateClosed
+		b.failures = 0
+		b.lastErr = nil
+	}
+	b.mu.Unlock()
+	b.notify(CircuitStateHalfOpen, to)
+}
+
+// stop cancels a scheduled probe. The circuit breaker does not change its state afterward.
+func (b *circuitBreaker) stop() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stopped = true
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+}
+
+func (b *circuitBreaker) notify(from, to CircuitState) {
+	if b.policy.OnStateChange != nil {
+		b.policy.OnStateChange(from, to)
+	}
+}
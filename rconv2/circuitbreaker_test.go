@@ -0,0 +1,143 @@
+package rconv2_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/floriansw/go-hll-rcon/rconv2"
+	"github.com/floriansw/go-hll-rcon/rconv2/rconv2test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Circuit breaker", func() {
+	var s *rconv2test.Server
+	var ctx context.Context
+	var down atomic.Bool
+	var dials atomic.Int32
+	var mu sync.Mutex
+	var changes []string
+	var p *rconv2.ConnectionPool
+
+	BeforeEach(func() {
+		var err error
+		s, err = rconv2test.NewServer(password)
+		Expect(err).ToNot(HaveOccurred())
+		s.Respond("GetServerInformation", map[string]any{})
+		ctx = context.Background()
+		down.Store(true)
+		dials.Store(0)
+		changes = nil
+
+		p = newPool(s, rconv2.ConnectionPoolOptions{
+			ReconnectPolicy: &rconv2.ReconnectPolicy{MaxAttempts: 1},
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				dials.Add(1)
+				if down.Load() {
+					return nil, syscall.ECONNREFUSED
+				}
+				var d net.Dialer
+				return d.DialContext(ctx, network, address)
+			},
+			CircuitBreaker: &rconv2.CircuitBreakerPolicy{
+				FailureThreshold: 3,
+				InitialBackoff:   50 * time.Millisecond,
+				MaxBackoff:       100 * time.Millisecond,
+				OnStateChange: func(from, to rconv2.CircuitState) {
+					// the callback may use the pool
+					_ = p.Stats()
+					mu.Lock()
+					defer mu.Unlock()
+					changes = append(changes, from.String()+"->"+to.String())
+				},
+			},
+		})
+	})
+
+	AfterEach(func() {
+		_, _ = p.Shutdown(ctx)
+		Expect(s.Close()).To(Succeed())
+	})
+
+	stateChanges := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, changes...)
+	}
+
+	It("opens after consecutive connection failures and fails fast", func() {
+		for range 3 {
+			_, err := p.Get(ctx)
+			Expect(errors.Is(err, rconv2.ErrCircuitOpen)).To(BeFalse())
+		}
+		Expect(p.CircuitState()).To(Equal(rconv2.CircuitStateOpen))
+		Expect(stateChanges()).To(Equal([]string{"closed->open"}))
+
+		n := dials.Load()
+		_, err := p.Get(ctx)
+		Expect(errors.Is(err, rconv2.ErrCircuitOpen)).To(BeTrue())
+		Expect(errors.Is(err, syscall.ECONNREFUSED)).To(BeTrue())
+		var open rconv2.CircuitOpen
+		Expect(errors.As(err, &open)).To(BeTrue())
+		Expect(open.Failures()).To(Equal(3))
+		Expect(open.RetryAfter()).To(BeNumerically("<=", 50*time.Millisecond))
+		Expect(dials.Load()).To(Equal(n))
+	})
+
+	It("does not count connection attempts aborted by the caller", func() {
+		for range 3 {
+			tctx, cancel := context.WithTimeout(ctx, 0)
+			_, err := p.Get(tctx)
+			cancel()
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		}
+
+		Expect(p.CircuitState()).To(Equal(rconv2.CircuitStateClosed))
+		Expect(stateChanges()).To(BeEmpty())
+	})
+
+	It("counts connection attempts which failed before the caller gave up", func() {
+		bp := newPool(s, rconv2.ConnectionPoolOptions{
+			ReconnectPolicy: &rconv2.ReconnectPolicy{DialTimeout: 100 * time.Millisecond},
+			// a server silently dropping packets
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+			CircuitBreaker: &rconv2.CircuitBreakerPolicy{FailureThreshold: 2},
+		})
+		defer bp.Shutdown(ctx)
+
+		for range 2 {
+			tctx, cancel := context.WithTimeout(ctx, 250*time.Millisecond)
+			_, err := bp.Get(tctx)
+			cancel()
+			Expect(errors.Is(err, rconv2.ErrCommandAborted)).To(BeTrue())
+		}
+
+		Expect(bp.CircuitState()).To(Equal(rconv2.CircuitStateOpen))
+	})
+
+	It("probes the server with a backoff until it is reachable again", func() {
+		for range 3 {
+			_, _ = p.Get(ctx)
+		}
+
+		Eventually(stateChanges).Should(Equal([]string{
+			"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->open",
+		}))
+		down.Store(false)
+
+		Eventually(p.CircuitState).Should(Equal(rconv2.CircuitStateClosed))
+		Expect(stateChanges()).To(HaveLen(7))
+		Expect(stateChanges()[6]).To(Equal("half-open->closed"))
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		p.Return(c, nil)
+	})
+})
@@ -2,77 +2,96 @@ package rconv2
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/floriansw/go-hll-rcon/rconv2/api"
 )
 
 // Connection represents a persistent connection to a HLL server using RCon. It can be used to issue commands against
 // the HLL server and query data. The connection can either be utilised using the higher-level API methods, or by sending
-// raw commands using ListCommand or Command.
+// raw commands using Command or CommandAs.
 //
-// A Connection is not thread-safe by default. Do not attempt to run multiple commands (either of the higher-level or
-// low-level API). Doing so may either run into non-expected indefinitely blocking execution (until the context.Context
-// deadline exceeds) or to mixed up data (sending a command and getting back the response for another command).
-// Instead, in goroutines use a ConnectionPool and request a new connection for each goroutine. The ConnectionPool will
-// ensure that one Connection is only used once at the same time. It also speeds up processing by opening a number of
-// Connections until the pool size is reached.
+// A Connection is safe for concurrent use by multiple goroutines. Commands issued at the same time are sent over the
+// same, authenticated TCP connection without waiting for the previous command to finish. The responses are matched to
+// their commands using the request ID the server echoes with each response.
+// A ConnectionPool can still be used to spread the load over multiple Connections or to re-use Connections between
+// otherwise unrelated parts of an application.
 type Connection struct {
 	id     string
 	socket *socket
+	// invoke runs a command through the interceptors of the Connection and sends it to the server.
+	invoke CommandInvoker
+	// strict reports unknown fields in responses as DecodeError
+	strict bool
+	// createdAt is the time the Connection was opened
+	createdAt time.Time
+	// idleSince is the time the Connection was returned to the pool of idle connections the last time
+	idleSince time.Time
+	// mutated is set once a command, which is not retryable, was sent with the Connection. It is reset by
+	// ConnectionPool.WithConnection.
+	mutated atomic.Bool
+	// inUse is set while the Connection is handed out by the ConnectionPool. It is guarded by the mutex of the
+	// ConnectionPool.
+	inUse bool
+	// normal is set while the Connection is in use by a request with PriorityNormal. It is guarded by the mutex of the
+	// ConnectionPool.
+	normal bool
 }
 
 func (c *Connection) Players(ctx context.Context) (*api.GetPlayersResponse, error) {
-	return execCommand[api.GetServerInformation, api.GetPlayersResponse](ctx, c.socket, api.GetServerInformation{
+	return execCommand[api.GetServerInformation, api.GetPlayersResponse](ctx, c, api.GetServerInformation{
 		Name: api.ServerInformationNamePlayers,
 	})
 }
 
 func (c *Connection) Player(ctx context.Context, playerId string) (*api.GetPlayerResponse, error) {
-	return execCommand[api.GetServerInformation, api.GetPlayerResponse](ctx, c.socket, api.GetServerInformation{
+	return execCommand[api.GetServerInformation, api.GetPlayerResponse](ctx, c, api.GetServerInformation{
 		Name:  api.ServerInformationNamePlayer,
 		Value: playerId,
 	})
 }
 
 func (c *Connection) ServerConfig(ctx context.Context) (*api.GetServerConfigResponse, error) {
-	return execCommand[api.GetServerInformation, api.GetServerConfigResponse](ctx, c.socket, api.GetServerInformation{
+	return execCommand[api.GetServerInformation, api.GetServerConfigResponse](ctx, c, api.GetServerInformation{
 		Name: api.ServerInformationNameServerConfig,
 	})
 }
 
 func (c *Connection) SessionInfo(ctx context.Context) (*api.GetSessionResponse, error) {
-	return execCommand[api.GetServerInformation, api.GetSessionResponse](ctx, c.socket, api.GetServerInformation{
+	return execCommand[api.GetServerInformation, api.GetSessionResponse](ctx, c, api.GetServerInformation{
 		Name: api.ServerInformationNameSession,
 	})
 }
 
 func (c *Connection) MapRotation(ctx context.Context) (*api.GetMapRotationResponse, error) {
-	return execCommand[api.GetServerInformation, api.GetMapRotationResponse](ctx, c.socket, api.GetServerInformation{
+	return execCommand[api.GetServerInformation, api.GetMapRotationResponse](ctx, c, api.GetServerInformation{
 		Name: api.ServerInformationNameMapRotation,
 	})
 }
 
 func (c *Connection) MapSequence(ctx context.Context) (*api.GetMapSequenceResponse, error) {
-	return execCommand[api.GetServerInformation, api.GetMapSequenceResponse](ctx, c.socket, api.GetServerInformation{
+	return execCommand[api.GetServerInformation, api.GetMapSequenceResponse](ctx, c, api.GetServerInformation{
 		Name: api.ServerInformationNameMapSequence,
 	})
 }
 
 func (c *Connection) DisplayableCommands(ctx context.Context) (*api.GetDisplayableCommandsResponse, error) {
-	return execCommand[api.GetDisplayableCommands, api.GetDisplayableCommandsResponse](ctx, c.socket, api.GetDisplayableCommands{})
+	return execCommand[api.GetDisplayableCommands, api.GetDisplayableCommandsResponse](ctx, c, api.GetDisplayableCommands{})
 }
 
 func (c *Connection) AdminLog(ctx context.Context, timeSeconds int32, filter string) (*api.GetAdminLogResponse, error) {
-	return execCommand[api.GetAdminLog, api.GetAdminLogResponse](ctx, c.socket, api.GetAdminLog{
+	return execCommand[api.GetAdminLog, api.GetAdminLogResponse](ctx, c, api.GetAdminLog{
 		LogBackTrackTime: timeSeconds,
 		Filters:          filter,
 	})
 }
 
 func (c *Connection) ChangeMap(ctx context.Context, mapName string) error {
-	_, err := execCommand[api.ChangeMap, any](ctx, c.socket, api.ChangeMap{
+	_, err := execCommand[api.ChangeMap, any](ctx, c, api.ChangeMap{
 		MapName: mapName,
 	})
 	return err
@@ -97,20 +116,20 @@ func (c *Connection) SetSectorLayout(ctx context.Context, sectors []string) erro
 			r.SectorFive = sector
 		}
 	}
-	_, err := execCommand[api.SetSectorLayout, any](ctx, c.socket, r)
+	_, err := execCommand[api.SetSectorLayout, any](ctx, c, r)
 	return err
 }
 
 func (c *Connection) AdminGroups(ctx context.Context) (*api.GetAdminGroupsResponse, error) {
-	return execCommand[api.GetAdminGroups, api.GetAdminGroupsResponse](ctx, c.socket, api.GetAdminGroups{})
+	return execCommand[api.GetAdminGroups, api.GetAdminGroupsResponse](ctx, c, api.GetAdminGroups{})
 }
 
 func (c *Connection) AdminUsers(ctx context.Context) (*api.GetAdminUsersResponse, error) {
-	return execCommand[api.GetAdminUsers, api.GetAdminUsersResponse](ctx, c.socket, api.GetAdminUsers{})
+	return execCommand[api.GetAdminUsers, api.GetAdminUsersResponse](ctx, c, api.GetAdminUsers{})
 }
 
 func (c *Connection) AddAdmin(ctx context.Context, playerId, adminGroup, comment string) error {
-	_, err := execCommand[api.AddAdmin, any](ctx, c.socket, api.AddAdmin{
+	_, err := execCommand[api.AddAdmin, any](ctx, c, api.AddAdmin{
 		PlayerId:   playerId,
 		AdminGroup: adminGroup,
 		Comment:    comment,
@@ -119,14 +138,14 @@ func (c *Connection) AddAdmin(ctx context.Context, playerId, adminGroup, comment
 }
 
 func (c *Connection) RemoveAdmin(ctx context.Context, playerId string) error {
-	_, err := execCommand[api.RemoveAdmin, any](ctx, c.socket, api.RemoveAdmin{
+	_, err := execCommand[api.RemoveAdmin, any](ctx, c, api.RemoveAdmin{
 		PlayerId: playerId,
 	})
 	return err
 }
 
 func (c *Connection) AddMapToRotation(ctx context.Context, mapName string, index int32) error {
-	_, err := execCommand[api.AddMapToRotation, any](ctx, c.socket, api.AddMapToRotation{
+	_, err := execCommand[api.AddMapToRotation, any](ctx, c, api.AddMapToRotation{
 		MapName: mapName,
 		Index:   index,
 	})
@@ -134,7 +153,7 @@ func (c *Connection) AddMapToRotation(ctx context.Context, mapName string, index
 }
 
 func (c *Connection) AddMapToSequence(ctx context.Context, mapName string, index int32) error {
-	_, err := execCommand[api.AddMapToSequence, any](ctx, c.socket, api.AddMapToSequence{
+	_, err := execCommand[api.AddMapToSequence, any](ctx, c, api.AddMapToSequence{
 		MapName: mapName,
 		Index:   index,
 	})
@@ -142,32 +161,32 @@ func (c *Connection) AddMapToSequence(ctx context.Context, mapName string, index
 }
 
 func (c *Connection) RemoveMapFromRotation(ctx context.Context, index int32) error {
-	_, err := execCommand[api.RemoveMapFromRotation, any](ctx, c.socket, api.RemoveMapFromRotation{
+	_, err := execCommand[api.RemoveMapFromRotation, any](ctx, c, api.RemoveMapFromRotation{
 		Index: index,
 	})
 	return err
 }
 
 func (c *Connection) RemoveMapToSequence(ctx context.Context, index int32) error {
-	_, err := execCommand[api.RemoveMapFromSequence, any](ctx, c.socket, api.RemoveMapFromSequence{
+	_, err := execCommand[api.RemoveMapFromSequence, any](ctx, c, api.RemoveMapFromSequence{
 		Index: index,
 	})
 	return err
 }
 
 func (c *Connection) SetMapShuffleEnabled(ctx context.Context, enable bool) error {
-	_, err := execCommand[api.SetMapShuffleEnabled, any](ctx, c.socket, api.SetMapShuffleEnabled{
+	_, err := execCommand[api.SetMapShuffleEnabled, any](ctx, c, api.SetMapShuffleEnabled{
 		Enable: enable,
 	})
 	return err
 }
 
 func (c *Connection) GetMapShuffleEnabled(ctx context.Context) (*api.GetMapShuffleEnabledResponse, error) {
-	return execCommand[api.GetMapShuffleEnabled, api.GetMapShuffleEnabledResponse](ctx, c.socket, api.GetMapShuffleEnabled{})
+	return execCommand[api.GetMapShuffleEnabled, api.GetMapShuffleEnabledResponse](ctx, c, api.GetMapShuffleEnabled{})
 }
 
 func (c *Connection) MoveMapInSequence(ctx context.Context, currentIndex, newIndex int32) error {
-	_, err := execCommand[api.MoveMapInSequence, any](ctx, c.socket, api.MoveMapInSequence{
+	_, err := execCommand[api.MoveMapInSequence, any](ctx, c, api.MoveMapInSequence{
 		CurrentIndex: currentIndex,
 		NewIndex:     newIndex,
 	})
@@ -175,14 +194,14 @@ func (c *Connection) MoveMapInSequence(ctx context.Context, currentIndex, newInd
 }
 
 func (c *Connection) SetTeamSwitchCooldown(ctx context.Context, timer int32) error {
-	_, err := execCommand[api.SetTeamSwitchCooldown, any](ctx, c.socket, api.SetTeamSwitchCooldown{
+	_, err := execCommand[api.SetTeamSwitchCooldown, any](ctx, c, api.SetTeamSwitchCooldown{
 		TeamSwitchTimer: timer,
 	})
 	return err
 }
 
 func (c *Connection) SetMatchTimer(ctx context.Context, gameMode api.GameMode, timer int32) error {
-	_, err := execCommand[api.SetMatchTimer, any](ctx, c.socket, api.SetMatchTimer{
+	_, err := execCommand[api.SetMatchTimer, any](ctx, c, api.SetMatchTimer{
 		GameMode:    gameMode,
 		MatchLength: timer,
 	})
@@ -190,14 +209,14 @@ func (c *Connection) SetMatchTimer(ctx context.Context, gameMode api.GameMode, t
 }
 
 func (c *Connection) RemoveMatchTimer(ctx context.Context, gameMode api.GameMode) error {
-	_, err := execCommand[api.RemoveMatchTimer, any](ctx, c.socket, api.RemoveMatchTimer{
+	_, err := execCommand[api.RemoveMatchTimer, any](ctx, c, api.RemoveMatchTimer{
 		GameMode: gameMode,
 	})
 	return err
 }
 
 func (c *Connection) SetWarmupTimer(ctx context.Context, gameMode api.GameMode, timer int32) error {
-	_, err := execCommand[api.SetWarmupTimer, any](ctx, c.socket, api.SetWarmupTimer{
+	_, err := execCommand[api.SetWarmupTimer, any](ctx, c, api.SetWarmupTimer{
 		GameMode:     gameMode,
 		WarmupLength: timer,
 	})
@@ -205,14 +224,14 @@ func (c *Connection) SetWarmupTimer(ctx context.Context, gameMode api.GameMode,
 }
 
 func (c *Connection) RemoveWarmupTimer(ctx context.Context, gameMode api.GameMode) error {
-	_, err := execCommand[api.RemoveWarmupTimer, any](ctx, c.socket, api.RemoveWarmupTimer{
+	_, err := execCommand[api.RemoveWarmupTimer, any](ctx, c, api.RemoveWarmupTimer{
 		GameMode: gameMode,
 	})
 	return err
 }
 
 func (c *Connection) SetDynamicWeatherEnabled(ctx context.Context, mapId string, enabled bool) error {
-	_, err := execCommand[api.SetDynamicWeatherEnabled, any](ctx, c.socket, api.SetDynamicWeatherEnabled{
+	_, err := execCommand[api.SetDynamicWeatherEnabled, any](ctx, c, api.SetDynamicWeatherEnabled{
 		MapId:  mapId,
 		Enable: enabled,
 	})
@@ -220,42 +239,42 @@ func (c *Connection) SetDynamicWeatherEnabled(ctx context.Context, mapId string,
 }
 
 func (c *Connection) SetMaxQueuedPlayers(ctx context.Context, maxQueuedPlayers int32) error {
-	_, err := execCommand[api.SetMaxQueuedPlayers, any](ctx, c.socket, api.SetMaxQueuedPlayers{
+	_, err := execCommand[api.SetMaxQueuedPlayers, any](ctx, c, api.SetMaxQueuedPlayers{
 		MaxQueuedPlayers: maxQueuedPlayers,
 	})
 	return err
 }
 
 func (c *Connection) SetIdleKickDuration(ctx context.Context, idleTimeoutMinutes int32) error {
-	_, err := execCommand[api.SetIdleKickDuration, any](ctx, c.socket, api.SetIdleKickDuration{
+	_, err := execCommand[api.SetIdleKickDuration, any](ctx, c, api.SetIdleKickDuration{
 		IdleTimeoutMinutes: idleTimeoutMinutes,
 	})
 	return err
 }
 
 func (c *Connection) SendServerMessage(ctx context.Context, msg string) error {
-	_, err := execCommand[api.SendServerMessage, any](ctx, c.socket, api.SendServerMessage{
+	_, err := execCommand[api.SendServerMessage, any](ctx, c, api.SendServerMessage{
 		Message: msg,
 	})
 	return err
 }
 
 func (c *Connection) ServerBroadcast(ctx context.Context, msg string) error {
-	_, err := execCommand[api.ServerBroadcast, any](ctx, c.socket, api.ServerBroadcast{
+	_, err := execCommand[api.ServerBroadcast, any](ctx, c, api.ServerBroadcast{
 		Message: msg,
 	})
 	return err
 }
 
 func (c *Connection) SetHighPingThreshold(ctx context.Context, highPingMs int32) error {
-	_, err := execCommand[api.SetHighPingThreshold, any](ctx, c.socket, api.SetHighPingThreshold{
+	_, err := execCommand[api.SetHighPingThreshold, any](ctx, c, api.SetHighPingThreshold{
 		HighPingThresholdMs: highPingMs,
 	})
 	return err
 }
 
 func (c *Connection) MessagePlayer(ctx context.Context, playerId, message string) error {
-	_, err := execCommand[api.MessagePlayer, any](ctx, c.socket, api.MessagePlayer{
+	_, err := execCommand[api.MessagePlayer, any](ctx, c, api.MessagePlayer{
 		Message:  message,
 		PlayerId: playerId,
 	})
@@ -263,7 +282,7 @@ func (c *Connection) MessagePlayer(ctx context.Context, playerId, message string
 }
 
 func (c *Connection) PunishPlayer(ctx context.Context, playerId, reason string) error {
-	_, err := execCommand[api.PunishPlayer, any](ctx, c.socket, api.PunishPlayer{
+	_, err := execCommand[api.PunishPlayer, any](ctx, c, api.PunishPlayer{
 		Reason:   reason,
 		PlayerId: playerId,
 	})
@@ -271,7 +290,7 @@ func (c *Connection) PunishPlayer(ctx context.Context, playerId, reason string)
 }
 
 func (c *Connection) KickPlayer(ctx context.Context, playerId, reason string) error {
-	_, err := execCommand[api.KickPlayer, any](ctx, c.socket, api.KickPlayer{
+	_, err := execCommand[api.KickPlayer, any](ctx, c, api.KickPlayer{
 		Reason:   reason,
 		PlayerId: playerId,
 	})
@@ -279,7 +298,7 @@ func (c *Connection) KickPlayer(ctx context.Context, playerId, reason string) er
 }
 
 func (c *Connection) TemporaryBanPlayer(ctx context.Context, playerId string, duration int32, reason, adminName string) error {
-	_, err := execCommand[api.TemporaryBanPlayer, any](ctx, c.socket, api.TemporaryBanPlayer{
+	_, err := execCommand[api.TemporaryBanPlayer, any](ctx, c, api.TemporaryBanPlayer{
 		Reason:    reason,
 		PlayerId:  playerId,
 		Duration:  duration,
@@ -289,18 +308,18 @@ func (c *Connection) TemporaryBanPlayer(ctx context.Context, playerId string, du
 }
 
 func (c *Connection) TemporaryBans(ctx context.Context) (*api.GetTemporaryBansResponse, error) {
-	return execCommand[api.GetTemporaryBans, api.GetTemporaryBansResponse](ctx, c.socket, api.GetTemporaryBans{})
+	return execCommand[api.GetTemporaryBans, api.GetTemporaryBansResponse](ctx, c, api.GetTemporaryBans{})
 }
 
 func (c *Connection) RemoveTemporaryBan(ctx context.Context, playerId string) error {
-	_, err := execCommand[api.RemoveTemporaryBan, any](ctx, c.socket, api.RemoveTemporaryBan{
+	_, err := execCommand[api.RemoveTemporaryBan, any](ctx, c, api.RemoveTemporaryBan{
 		PlayerId: playerId,
 	})
 	return err
 }
 
 func (c *Connection) PermanentBanPlayer(ctx context.Context, playerId, reason, adminName string) error {
-	_, err := execCommand[api.PermanentBanPlayer, any](ctx, c.socket, api.PermanentBanPlayer{
+	_, err := execCommand[api.PermanentBanPlayer, any](ctx, c, api.PermanentBanPlayer{
 		Reason:    reason,
 		PlayerId:  playerId,
 		AdminName: adminName,
@@ -309,65 +328,65 @@ func (c *Connection) PermanentBanPlayer(ctx context.Context, playerId, reason, a
 }
 
 func (c *Connection) PermanentBans(ctx context.Context) (*api.GetPermanentBansResponse, error) {
-	return execCommand[api.GetPermanentBans, api.GetPermanentBansResponse](ctx, c.socket, api.GetPermanentBans{})
+	return execCommand[api.GetPermanentBans, api.GetPermanentBansResponse](ctx, c, api.GetPermanentBans{})
 }
 
 func (c *Connection) RemovePermanentBan(ctx context.Context, playerId string) error {
-	_, err := execCommand[api.RemovePermanentBan, any](ctx, c.socket, api.RemovePermanentBan{
+	_, err := execCommand[api.RemovePermanentBan, any](ctx, c, api.RemovePermanentBan{
 		PlayerId: playerId,
 	})
 	return err
 }
 
 func (c *Connection) SetAutoBalance(ctx context.Context, enable bool) error {
-	_, err := execCommand[api.SetAutoBalance, any](ctx, c.socket, api.SetAutoBalance{
+	_, err := execCommand[api.SetAutoBalance, any](ctx, c, api.SetAutoBalance{
 		EnableAutoBalance: enable,
 	})
 	return err
 }
 
 func (c *Connection) SetAutoBalanceThreshold(ctx context.Context, threshold int32) error {
-	_, err := execCommand[api.SetAutoBalanceThreshold, any](ctx, c.socket, api.SetAutoBalanceThreshold{
+	_, err := execCommand[api.SetAutoBalanceThreshold, any](ctx, c, api.SetAutoBalanceThreshold{
 		AutoBalanceThreshold: threshold,
 	})
 	return err
 }
 
 func (c *Connection) SetVoteKick(ctx context.Context, enabled bool) error {
-	_, err := execCommand[api.SetVoteKick, any](ctx, c.socket, api.SetVoteKick{
+	_, err := execCommand[api.SetVoteKick, any](ctx, c, api.SetVoteKick{
 		Enabled: enabled,
 	})
 	return err
 }
 
 func (c *Connection) ResetVoteKickThreshold(ctx context.Context) error {
-	_, err := execCommand[api.ResetVoteKickThreshold, any](ctx, c.socket, api.ResetVoteKickThreshold{})
+	_, err := execCommand[api.ResetVoteKickThreshold, any](ctx, c, api.ResetVoteKickThreshold{})
 	return err
 }
 
 func (c *Connection) SetVoteKickThreshold(ctx context.Context, threshold string) error {
-	_, err := execCommand[api.SetVoteKickThreshold, any](ctx, c.socket, api.SetVoteKickThreshold{
+	_, err := execCommand[api.SetVoteKickThreshold, any](ctx, c, api.SetVoteKickThreshold{
 		ThresholdValue: threshold,
 	})
 	return err
 }
 
 func (c *Connection) SetWelcomeMessage(ctx context.Context, message string) error {
-	_, err := execCommand[api.SetWelcomeMessage, any](ctx, c.socket, api.SetWelcomeMessage{
+	_, err := execCommand[api.SetWelcomeMessage, any](ctx, c, api.SetWelcomeMessage{
 		Message: message,
 	})
 	return err
 }
 
 func (c *Connection) SetVipSlotCount(ctx context.Context, count int32) error {
-	_, err := execCommand[api.SetVipSlotCount, any](ctx, c.socket, api.SetVipSlotCount{
+	_, err := execCommand[api.SetVipSlotCount, any](ctx, c, api.SetVipSlotCount{
 		VipSlotCount: count,
 	})
 	return err
 }
 
 func (c *Connection) ForceTeamSwitch(ctx context.Context, playerId string, mode api.ForceMode) error {
-	_, err := execCommand[api.ForceTeamSwitch, any](ctx, c.socket, api.ForceTeamSwitch{
+	_, err := execCommand[api.ForceTeamSwitch, any](ctx, c, api.ForceTeamSwitch{
 		PlayerId:  playerId,
 		ForceMode: mode,
 	})
@@ -375,21 +394,21 @@ func (c *Connection) ForceTeamSwitch(ctx context.Context, playerId string, mode
 }
 
 func (c *Connection) AddBannedWords(ctx context.Context, words []string) error {
-	_, err := execCommand[api.AddBannedWords, any](ctx, c.socket, api.AddBannedWords{
+	_, err := execCommand[api.AddBannedWords, any](ctx, c, api.AddBannedWords{
 		BannedWords: strings.Join(words, ","),
 	})
 	return err
 }
 
 func (c *Connection) RemoveBannedWords(ctx context.Context, words []string) error {
-	_, err := execCommand[api.RemoveBannedWords, any](ctx, c.socket, api.RemoveBannedWords{
+	_, err := execCommand[api.RemoveBannedWords, any](ctx, c, api.RemoveBannedWords{
 		BannedWords: strings.Join(words, ","),
 	})
 	return err
 }
 
 func (c *Connection) AddVip(ctx context.Context, playerId, comment string) error {
-	_, err := execCommand[api.AddVip, any](ctx, c.socket, api.AddVip{
+	_, err := execCommand[api.AddVip, any](ctx, c, api.AddVip{
 		PlayerId: playerId,
 		Comment:  comment,
 	})
@@ -397,14 +416,14 @@ func (c *Connection) AddVip(ctx context.Context, playerId, comment string) error
 }
 
 func (c *Connection) RemoveVip(ctx context.Context, playerId string) error {
-	_, err := execCommand[api.RemoveVip, any](ctx, c.socket, api.RemoveVip{
+	_, err := execCommand[api.RemoveVip, any](ctx, c, api.RemoveVip{
 		PlayerId: playerId,
 	})
 	return err
 }
 
 func (c *Connection) RemovePlayerFromPlatoon(ctx context.Context, playerId, reason string) error {
-	_, err := execCommand[api.RemovePlayerFromPlatoon, any](ctx, c.socket, api.RemovePlayerFromPlatoon{
+	_, err := execCommand[api.RemovePlayerFromPlatoon, any](ctx, c, api.RemovePlayerFromPlatoon{
 		PlayerId: playerId,
 		Reason:   reason,
 	})
@@ -412,7 +431,7 @@ func (c *Connection) RemovePlayerFromPlatoon(ctx context.Context, playerId, reas
 }
 
 func (c *Connection) DisbandPlatoon(ctx context.Context, team, squad int32, reason string) error {
-	_, err := execCommand[api.DisbandPlatoon, any](ctx, c.socket, api.DisbandPlatoon{
+	_, err := execCommand[api.DisbandPlatoon, any](ctx, c, api.DisbandPlatoon{
 		TeamIndex:  team,
 		SquadIndex: squad,
 		Reason:     reason,
@@ -461,24 +480,126 @@ func filter(maps []string, filters ...MapFilter) []string {
 }
 
 func (c *Connection) GetClientReferenceData(ctx context.Context, command string) (*api.GetClientReferenceDataResponse, error) {
-	return execCommand[api.GetClientReferenceData, api.GetClientReferenceDataResponse](ctx, c.socket, api.GetClientReferenceData(command))
+	return execCommand[api.GetClientReferenceData, api.GetClientReferenceDataResponse](ctx, c, api.GetClientReferenceData(command))
 }
 
-func execCommand[T, U any](ctx context.Context, so *socket, req T) (result *U, err error) {
-	err = so.SetContext(ctx)
-	if err != nil {
+// Command sends the command with the given name to the server and returns the contentBody of the response as sent by
+// the server. This can be used to send commands which do not (yet) have a higher-level API method. The contentBody is
+// nil, if the server responded without one, which is the case for most commands changing the state of the server.
+//
+// The body is sent as is, if it is a string, otherwise it is JSON encoded. Use nil for commands without parameters.
+// A response with a status code other than 200 is returned as UnexpectedStatus.
+func (c *Connection) Command(ctx context.Context, name string, body any) (json.RawMessage, error) {
+	res, err := execNamedCommand[string](ctx, c, name, body)
+	if err != nil || *res == "" {
 		return nil, err
 	}
-	r := Request[T, U]{
-		Body: req,
+	return json.RawMessage(*res), nil
+}
+
+// CommandAs sends the command with the given name to the server, the same way as Connection.Command, and decodes the
+// contentBody of the response into a value of type T.
+func CommandAs[T any](ctx context.Context, c *Connection, name string, body any) (*T, error) {
+	return execNamedCommand[T](ctx, c, name, body)
+}
+
+// CommandAsRaw sends the command with the given name to the server and decodes the contentBody of the response into a
+// value of type T, the same way as CommandAs. Additionally, it returns the contentBody of the response as sent by the
+// server. The contentBody is returned even if it could not be decoded, next to the DecodeError. It is nil, if the server
+// responded without one or with a status code other than 200.
+func CommandAsRaw[T any](ctx context.Context, c *Connection, name string, body any) (*T, json.RawMessage, error) {
+	return execNamedCommandRaw[T](ctx, c, name, body)
+}
+
+func execCommand[T, U any](ctx context.Context, c *Connection, req T) (result *U, err error) {
+	return execNamedCommand[U](ctx, c, commandName(req), req)
+}
+
+func execNamedCommand[U any](ctx context.Context, c *Connection, name string, req any) (result *U, err error) {
+	result, _, err = execNamedCommandRaw[U](ctx, c, name, req)
+	return result, err
+}
+
+func execNamedCommandRaw[U any](ctx context.Context, c *Connection, name string, req any) (result *U, raw json.RawMessage, err error) {
+	if v, ok := req.(ValidatableCommand); ok {
+		if err := v.Validate(); err != nil {
+			return nil, nil, err
+		}
+	}
+	call := &CommandCall{
+		ConnectionId: c.id,
+		Name:         name,
+		Body:         req,
 	}
-	res, err := r.do(so)
+	res, err := c.invoke(ctx, call)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if res.StatusCode != 200 {
+		return nil, nil, newCommandUnexpectedStatus(call.Name, res.StatusCode, res.StatusMessage)
+	}
+	if res.Content != "" {
+		raw = json.RawMessage(res.Content)
+	}
+	r := Response[U]{
+		StatusCode:    res.StatusCode,
+		StatusMessage: res.StatusMessage,
+		Command:       call.Name,
+		Content:       res.Content,
+	}
+	body, err := r.decode(c.strict)
+	if err != nil {
+		return nil, raw, err
+	}
+	return &body, raw, nil
+}
+
+// ping sends a cheap read command to check whether the server still answers to commands of the Connection. The
+// command is not run through the interceptors of the Connection.
+func (c *Connection) ping(ctx context.Context) error {
+	res, err := c.send(ctx, &CommandCall{
+		ConnectionId: c.id,
+		Name:         "GetServerInformation",
+		Body:         api.GetServerInformation{Name: api.ServerInformationNameServerConfig},
+	})
+	if err != nil {
+		return err
 	}
 	if res.StatusCode != 200 {
-		return nil, NewUnexpectedStatus(res.StatusCode, res.StatusMessage)
+		return newCommandUnexpectedStatus("GetServerInformation", res.StatusCode, res.StatusMessage)
+	}
+	return nil
+}
+
+// idleExpired reports whether the Connection is idle for longer than maxIdleTime. A maxIdleTime of 0 never expires.
+func (c *Connection) idleExpired(maxIdleTime time.Duration, now time.Time) bool {
+	return maxIdleTime > 0 && now.Sub(c.idleSince) >= maxIdleTime
+}
+
+// send is the last CommandInvoker of the interceptor chain, which actually sends the command to the server.
+func (c *Connection) send(ctx context.Context, call *CommandCall) (*CommandResult, error) {
+	started := time.Now()
+	if !IsRetryable(call.Name) {
+		c.mutated.Store(true)
+	}
+	req := newRawRequest(call.Name, call.Body)
+	gen := c.socket.generation()
+	res, err := c.socket.exchange(ctx, req)
+	if err == nil && res.StatusCode == 401 {
+		// the server does not accept the auth token anymore, e.g. because it restarted in the meantime. Retry the
+		// command once with a fresh auth token.
+		if err = c.socket.reauthenticate(ctx, gen); err != nil {
+			return nil, err
+		}
+		res, err = c.socket.exchange(ctx, req)
+	}
+	if err != nil {
+		return nil, err
 	}
-	body := res.Body()
-	return &body, nil
+	return &CommandResult{
+		StatusCode:    res.StatusCode,
+		StatusMessage: res.StatusMessage,
+		Content:       res.Content,
+		Duration:      time.Since(started),
+	}, nil
 }
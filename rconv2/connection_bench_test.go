@@ -0,0 +1,48 @@
+package rconv2_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/floriansw/go-hll-rcon/rconv2"
+	"github.com/floriansw/go-hll-rcon/rconv2/api"
+	"github.com/floriansw/go-hll-rcon/rconv2/rconv2test"
+)
+
+// BenchmarkConnection_Players measures polling the player list of a full server. The allocations include the ones of
+// the fake server and of decoding the response into api.GetPlayersResponse.
+func BenchmarkConnection_Players(b *testing.B) {
+	s, err := rconv2test.NewServer("password")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer s.Close()
+	var players api.GetPlayersResponse
+	for i := 0; i < 100; i++ {
+		players.Players = append(players.Players, api.GetPlayerResponse{
+			Id:   "7656119800000" + strconv.Itoa(1000+i),
+			Name: "Player " + strconv.Itoa(i),
+		})
+	}
+	s.Respond("GetServerInformation", players)
+	p, err := rconv2.NewConnectionPool(rconv2.ConnectionPoolOptions{Hostname: s.Host(), Port: s.Port(), Password: "password"})
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer p.Shutdown(context.Background())
+	ctx := context.Background()
+	c, err := p.Get(ctx)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer p.Return(c, nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.Players(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
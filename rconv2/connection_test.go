@@ -0,0 +1,334 @@
+package rconv2_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/floriansw/go-hll-rcon/rconv2"
+	"github.com/floriansw/go-hll-rcon/rconv2/api"
+	"github.com/floriansw/go-hll-rcon/rconv2/rconv2test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	password = "secret"
+)
+
+func newPool(s *rconv2test.Server, opts rconv2.ConnectionPoolOptions) *rconv2.ConnectionPool {
+	opts.Hostname = s.Host()
+	opts.Port = s.Port()
+	if opts.Password == "" {
+		opts.Password = password
+	}
+	p, err := rconv2.NewConnectionPool(opts)
+	Expect(err).ToNot(HaveOccurred())
+	return p
+}
+
+var _ = Describe("Connection", func() {
+	var s *rconv2test.Server
+	var p *rconv2.ConnectionPool
+	var ctx context.Context
+
+	BeforeEach(func() {
+		var err error
+		s, err = rconv2test.NewServer(password)
+		Expect(err).ToNot(HaveOccurred())
+		p = newPool(s, rconv2.ConnectionPoolOptions{})
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		p.Shutdown(context.Background())
+		Expect(s.Close()).To(Succeed())
+	})
+
+	It("authenticates and executes commands", func() {
+		s.Handle("GetServerInformation", func(r rconv2test.Request) rconv2test.Response {
+			var req api.GetServerInformation
+			Expect(r.Bind(&req)).To(Succeed())
+			Expect(req.Name).To(BeEquivalentTo(api.ServerInformationNamePlayers))
+			return rconv2test.Response{Content: api.GetPlayersResponse{Players: []api.GetPlayerResponse{{Id: "1", Name: "Player"}}}}
+		})
+
+		err := p.WithConnection(ctx, func(c *rconv2.Connection) error {
+			res, err := c.Players(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Players).To(HaveLen(1))
+			Expect(res.Players[0].Name).To(Equal("Player"))
+			return nil
+		})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.RequestsFor(rconv2test.CommandLogin)).To(HaveLen(1))
+	})
+
+	It("fails with wrong credentials", func() {
+		_, err := newPool(s, rconv2.ConnectionPoolOptions{Password: "wrong"}).Get(ctx)
+
+		Expect(errors.Is(err, rconv2.ErrInvalidCredentials)).To(BeTrue())
+		Expect(s.RequestsFor(rconv2test.CommandLogin)).To(HaveLen(1))
+	})
+
+	It("returns unexpected status codes as error", func() {
+		s.Handle("KickPlayer", func(r rconv2test.Request) rconv2test.Response {
+			return rconv2test.Response{StatusCode: 500, StatusMessage: "internal error"}
+		})
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		defer p.Return(c, nil)
+
+		err = c.KickPlayer(ctx, "1", "reason")
+
+		Expect(err).To(BeAssignableToTypeOf(&rconv2.UnexpectedStatus{}))
+	})
+
+	It("does not send invalid requests", func() {
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		defer p.Return(c, nil)
+
+		err = c.KickPlayer(ctx, "", "reason")
+
+		Expect(err).To(BeAssignableToTypeOf(&api.ValidationError{}))
+		Expect(s.RequestsFor("KickPlayer")).To(BeEmpty())
+	})
+
+	It("matches concurrent responses to their requests", func() {
+		s.Handle("GetServerInformation", func(r rconv2test.Request) rconv2test.Response {
+			var req api.GetServerInformation
+			Expect(r.Bind(&req)).To(Succeed())
+			// answer the first request last
+			d := time.Duration(0)
+			if req.Value == "0" {
+				d = 100 * time.Millisecond
+			}
+			return rconv2test.Response{Content: api.GetPlayerResponse{Id: req.Value}, Delay: d}
+		})
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		defer p.Return(c, nil)
+
+		var wg sync.WaitGroup
+		for _, id := range []string{"0", "1", "2", "3", "4"} {
+			wg.Add(1)
+			go func(id string) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				res, err := c.Player(ctx, id)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res.Id).To(Equal(id))
+			}(id)
+			if id == "0" {
+				Eventually(func() int { return len(s.RequestsFor("GetServerInformation")) }).Should(Equal(1))
+			}
+		}
+		wg.Wait()
+
+		Expect(s.Accepted()).To(Equal(1))
+	})
+
+	It("fails requests when the server disconnects", func() {
+		s.Handle("GetServerInformation", func(r rconv2test.Request) rconv2test.Response {
+			return rconv2test.Response{Disconnect: true}
+		})
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = c.SessionInfo(ctx)
+		p.Return(c, err)
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("reconnects after the server closed the connection", func() {
+		s.Respond("GetServerInformation", api.GetSessionResponse{MapName: "CARENTAN"})
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		defer p.Return(c, nil)
+		_, err = c.SessionInfo(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		s.DisconnectAll()
+		Eventually(s.OpenConnections).Should(Equal(0))
+
+		Eventually(func() error {
+			_, err := c.SessionInfo(ctx)
+			return err
+		}).Should(Succeed())
+		Expect(s.Accepted()).To(Equal(2))
+	})
+
+	It("aborts commands when the context is cancelled", func() {
+		s.Handle("GetServerInformation", func(r rconv2test.Request) rconv2test.Response {
+			return rconv2test.Response{Delay: 5 * time.Second}
+		})
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cctx, cancel := context.WithCancel(ctx)
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		started := time.Now()
+		_, err = c.SessionInfo(cctx)
+		p.Return(c, err)
+
+		Expect(time.Since(started)).To(BeNumerically("<", time.Second))
+		Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		Expect(rconv2.IsBrokenHllConnection(err)).To(BeTrue())
+	})
+
+	It("re-authenticates when the server does not accept the auth token anymore", func() {
+		var reauths []error
+		p = newPool(s, rconv2.ConnectionPoolOptions{
+			Hooks: rconv2.PoolHooks{
+				OnReauthenticated: func(connectionId string, err error) {
+					reauths = append(reauths, err)
+				},
+			},
+		})
+		s.Respond("GetServerInformation", api.GetSessionResponse{MapName: "CARENTAN"})
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		defer p.Return(c, nil)
+
+		s.InvalidateTokens()
+		res, err := c.SessionInfo(ctx)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.MapName).To(Equal("CARENTAN"))
+		Expect(reauths).To(Equal([]error{nil}))
+		Expect(s.RequestsFor(rconv2test.CommandLogin)).To(HaveLen(2))
+		Expect(s.Accepted()).To(Equal(1))
+	})
+
+	It("resolves DNS names", func() {
+		opts := rconv2.ConnectionPoolOptions{Hostname: "localhost", Port: s.Port(), Password: password}
+		lp, err := rconv2.NewConnectionPool(opts)
+		Expect(err).ToNot(HaveOccurred())
+		defer lp.Shutdown(context.Background())
+
+		c, err := lp.Get(ctx)
+
+		Expect(err).ToNot(HaveOccurred())
+		lp.Return(c, nil)
+	})
+
+	It("opens connections with a custom dial function", func() {
+		var addresses []string
+		lp, err := rconv2.NewConnectionPool(rconv2.ConnectionPoolOptions{
+			Hostname: "rcon.example.com",
+			Port:     7779,
+			Password: password,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				addresses = append(addresses, address)
+				var d net.Dialer
+				return d.DialContext(ctx, network, s.Addr())
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		defer lp.Shutdown(context.Background())
+
+		c, err := lp.Get(ctx)
+
+		Expect(err).ToNot(HaveOccurred())
+		lp.Return(c, nil)
+		Expect(addresses).To(Equal([]string{"rcon.example.com:7779"}))
+	})
+
+	It("gives up the connection when the server violates the protocol", func() {
+		s.Handle("GetServerInformation", func(r rconv2test.Request) rconv2test.Response {
+			return rconv2test.Response{Raw: []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}}
+		})
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = c.SessionInfo(ctx)
+		p.Return(c, err)
+
+		Expect(errors.Is(err, rconv2.ErrProtocolDesync)).To(BeTrue())
+		Expect(rconv2.IsBrokenHllConnection(err)).To(BeTrue())
+	})
+
+	It("rejects responses exceeding the maximum frame size", func() {
+		p = newPool(s, rconv2.ConnectionPoolOptions{MaxFrameSize: ptr(256)})
+		s.Respond("GetServerInformation", api.GetSessionResponse{ServerName: strings.Repeat("a", 512)})
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = c.SessionInfo(ctx)
+		p.Return(c, err)
+
+		Expect(errors.Is(err, rconv2.ErrProtocolDesync)).To(BeTrue())
+	})
+})
+
+var _ = Describe("Connection raw commands", func() {
+	var s *rconv2test.Server
+	var c *rconv2.Connection
+	var p *rconv2.ConnectionPool
+	var ctx context.Context
+
+	type banList struct {
+		Bans []string `json:"bans"`
+	}
+
+	BeforeEach(func() {
+		var err error
+		s, err = rconv2test.NewServer(password)
+		Expect(err).ToNot(HaveOccurred())
+		s.Handle("GetFutureBans", func(r rconv2test.Request) rconv2test.Response {
+			return rconv2test.Response{Content: banList{Bans: []string{r.Body}}}
+		})
+		p = newPool(s, rconv2.ConnectionPoolOptions{})
+		ctx = context.Background()
+		c, err = p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		p.Return(c, nil)
+		p.Shutdown(context.Background())
+		Expect(s.Close()).To(Succeed())
+	})
+
+	It("sends raw commands", func() {
+		res, err := c.Command(ctx, "GetFutureBans", map[string]int{"Page": 1})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).To(MatchJSON(`{"bans":["{\"Page\":1}"]}`))
+	})
+
+	It("returns no content for raw commands the server responded to without one", func() {
+		s.Respond("MessagePlayer", nil)
+
+		res, err := c.Command(ctx, "MessagePlayer", map[string]string{"PlayerId": "1", "Message": "hello"})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).To(BeNil())
+		_, err = json.Marshal(res)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("sends raw commands with a typed response", func() {
+		res, err := rconv2.CommandAs[banList](ctx, c, "GetFutureBans", "raw")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.Bans).To(Equal([]string{"raw"}))
+	})
+
+	It("returns unexpected status codes of raw commands", func() {
+		_, err := c.Command(ctx, "DoesNotExist", nil)
+
+		Expect(errors.Is(err, rconv2.ErrNotFound)).To(BeTrue())
+	})
+})
+
+func ptr[T any](v T) *T {
+	return &v
+}
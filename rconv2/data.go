@@ -1,17 +1,61 @@
 package rconv2
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 )
 
 var (
 	ErrInvalidCredentials = errors.New("wrong password")
+	// ErrCommandAborted is returned when a command was aborted because its context.Context was cancelled or its
+	// deadline exceeded. The error also wraps the error of the context.Context (context.Canceled or
+	// context.DeadlineExceeded).
+	ErrCommandAborted = errors.New("command aborted")
+	// ErrPoolClosed is returned by ConnectionPool.Get once the pool was shut down, as well as to requests waiting for a
+	// connection when the pool is shut down.
+	ErrPoolClosed = errors.New("connection pool closed")
+
+	// The following errors can be used with errors.Is to check the class of the status code of an UnexpectedStatus.
+
+	// ErrBadRequest matches UnexpectedStatus errors with a 400 status code, usually because of invalid parameters.
+	ErrBadRequest = errors.New("bad request")
+	// ErrUnauthorized matches UnexpectedStatus errors with a 401 status code.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden matches UnexpectedStatus errors with a 403 status code.
+	ErrForbidden = errors.New("forbidden")
+	// ErrNotFound matches UnexpectedStatus errors with a 404 status code, as well as command-specific errors
+	// describing that something could not be found, like ErrPlayerNotFound.
+	ErrNotFound = errors.New("not found")
+	// ErrServerError matches UnexpectedStatus errors with a 5xx status code.
+	ErrServerError = errors.New("server error")
+
+	// ErrPlayerNotFound matches UnexpectedStatus errors of commands targeting a connected player, where the server
+	// responded with the well known message that the player could not be found, e.g. when kicking a player that is not
+	// connected to the server (anymore).
+	ErrPlayerNotFound = errors.New("player not found")
 )
 
+// playerNotFoundMessages are the status messages by command, with which the server responds to commands targeting a
+// player that is not connected to the server. Other messages do not match ErrPlayerNotFound, even if they describe
+// something that could not be found.
+var playerNotFoundMessages = map[string][]string{
+	"ForceTeamSwitch":         {"player not found"},
+	"KickPlayer":              {"player not found"},
+	"MessagePlayer":           {"player not found"},
+	"PunishPlayer":            {"player not found"},
+	"RemovePlayerFromPlatoon": {"player not found"},
+}
+
+// UnexpectedStatus is returned when the server responded to a command with a status code other than 200.
+// Use errors.Is with one of ErrBadRequest, ErrUnauthorized, ErrForbidden, ErrNotFound or ErrServerError to check the
+// class of the status code, or with a command-specific error, like ErrPlayerNotFound.
 type UnexpectedStatus struct {
 	code    int
 	message string
+	command string
 }
 
 func NewUnexpectedStatus(code int, message string) *UnexpectedStatus {
@@ -21,14 +65,87 @@ func NewUnexpectedStatus(code int, message string) *UnexpectedStatus {
 	}
 }
 
+func newCommandUnexpectedStatus(command string, code int, message string) *UnexpectedStatus {
+	u := NewUnexpectedStatus(code, message)
+	u.command = command
+	return u
+}
+
+// Code returns the status code the server responded with.
+func (u UnexpectedStatus) Code() int {
+	return u.code
+}
+
+// Message returns the status message the server responded with.
+func (u UnexpectedStatus) Message() string {
+	return u.message
+}
+
+// Command returns the name of the command the server responded to. It is empty, if the status was not received as
+// a response to a command (e.g. during the handshake).
+func (u UnexpectedStatus) Command() string {
+	return u.command
+}
+
 func (u UnexpectedStatus) Error() string {
+	if u.command != "" {
+		return fmt.Sprintf("invalid status code received for %s, got %d with message %s", u.command, u.code, u.message)
+	}
 	return fmt.Sprintf("invalid status code received, got %d with message %s", u.code, u.message)
 }
 
+func (u UnexpectedStatus) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return u.code == 400
+	case ErrUnauthorized:
+		return u.code == 401
+	case ErrForbidden:
+		return u.code == 403
+	case ErrNotFound:
+		return u.code == 404 || u.isPlayerNotFound()
+	case ErrServerError:
+		return u.code >= 500 && u.code < 600
+	case ErrPlayerNotFound:
+		return u.isPlayerNotFound()
+	}
+	return false
+}
+
+func (u UnexpectedStatus) isPlayerNotFound() bool {
+	if u.code == 200 {
+		return false
+	}
+	return slices.ContainsFunc(playerNotFoundMessages[u.command], func(m string) bool {
+		return strings.EqualFold(strings.TrimSpace(u.message), m)
+	})
+}
+
 type Command interface {
 	CommandName() string
 }
 
+// DecodeError is returned when the contentBody of a response to a command could not be decoded into the response type
+// of the command, e.g. because the game changed the type of a field.
+type DecodeError struct {
+	// Command is the name of the command the response was received for.
+	Command string
+	// Content is the contentBody of the response as sent by the server.
+	Content string
+	Err     error
+}
+
+func (d *DecodeError) Error() string {
+	return fmt.Sprintf("decode response of %s: %s", d.Command, d.Err)
+}
+
+func (d *DecodeError) Unwrap() error {
+	return d.Err
+}
+
+// ValidatableCommand is implemented by requests, which validate their values before they are sent to the server.
+// Requests failing the validation are not sent, instead the error returned by Validate is returned as is. Requests of
+// the api package return an *api.ValidationError listing all invalid fields.
 type ValidatableCommand interface {
 	Validate() error
 }
@@ -50,3 +167,48 @@ func (c connectionRequestTimeout) Error() string {
 func (c connectionRequestTimeout) Timeout() bool {
 	return true
 }
+
+func newResponseTimeout(requestId uint32) responseTimeout {
+	return responseTimeout{
+		requestId: requestId,
+	}
+}
+
+type responseTimeout struct {
+	requestId uint32
+}
+
+func (r responseTimeout) Error() string {
+	return fmt.Sprintf("no response received for request %d before the deadline exceeded", r.requestId)
+}
+
+func (r responseTimeout) Timeout() bool {
+	return true
+}
+
+func newCommandAborted(ctx context.Context) error {
+	return fmt.Errorf("%w: %w", ErrCommandAborted, ctx.Err())
+}
+
+// connectAborted is returned when connecting to the server was aborted by the caller after at least one connection
+// attempt failed already. Unlike a plain ErrCommandAborted, it tells that the server could not be reached.
+type connectAborted struct {
+	aborted error
+	lastErr error
+}
+
+func (c connectAborted) Error() string {
+	return fmt.Sprintf("%s, last connection attempt failed: %s", c.aborted, c.lastErr)
+}
+
+func (c connectAborted) Unwrap() []error {
+	return []error{c.aborted, c.lastErr}
+}
+
+// abortedAfter wraps the error of an aborted connection attempt with the error of the last failed attempt, if any.
+func abortedAfter(aborted, lastErr error) error {
+	if lastErr == nil {
+		return aborted
+	}
+	return connectAborted{aborted: aborted, lastErr: lastErr}
+}
@@ -0,0 +1,79 @@
+package rconv2_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/floriansw/go-hll-rcon/rconv2"
+	"github.com/floriansw/go-hll-rcon/rconv2/rconv2test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UnexpectedStatus", func() {
+	var s *rconv2test.Server
+	var p *rconv2.ConnectionPool
+	var ctx context.Context
+
+	BeforeEach(func() {
+		var err error
+		s, err = rconv2test.NewServer(password)
+		Expect(err).ToNot(HaveOccurred())
+		p = newPool(s, rconv2.ConnectionPoolOptions{})
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		p.Shutdown(context.Background())
+		Expect(s.Close()).To(Succeed())
+	})
+
+	kick := func(code int, message string) error {
+		s.Handle("KickPlayer", func(r rconv2test.Request) rconv2test.Response {
+			return rconv2test.Response{StatusCode: code, StatusMessage: message}
+		})
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		defer p.Return(c, nil)
+
+		err = c.KickPlayer(ctx, "1", "reason")
+		Expect(err).To(HaveOccurred())
+		var status *rconv2.UnexpectedStatus
+		Expect(errors.As(err, &status)).To(BeTrue())
+		Expect(status.Code()).To(Equal(code))
+		Expect(status.Message()).To(Equal(message))
+		Expect(status.Command()).To(Equal("KickPlayer"))
+		return err
+	}
+
+	It("matches the class of the status code", func() {
+		Expect(errors.Is(kick(400, "invalid parameters"), rconv2.ErrBadRequest)).To(BeTrue())
+		Expect(errors.Is(kick(500, "internal error"), rconv2.ErrServerError)).To(BeTrue())
+		Expect(errors.Is(kick(500, "internal error"), rconv2.ErrBadRequest)).To(BeFalse())
+	})
+
+	It("matches command-specific errors", func() {
+		err := kick(400, "Player not found")
+
+		Expect(errors.Is(err, rconv2.ErrPlayerNotFound)).To(BeTrue())
+		Expect(errors.Is(err, rconv2.ErrNotFound)).To(BeTrue())
+		Expect(errors.Is(err, rconv2.ErrBadRequest)).To(BeTrue())
+		Expect(errors.Is(kick(400, "invalid parameters"), rconv2.ErrPlayerNotFound)).To(BeFalse())
+		Expect(errors.Is(kick(400, "player not found in platoon"), rconv2.ErrPlayerNotFound)).To(BeFalse())
+	})
+
+	It("does not match command-specific errors of other commands", func() {
+		s.Handle("RemoveAdmin", func(r rconv2test.Request) rconv2test.Response {
+			return rconv2test.Response{StatusCode: 400, StatusMessage: "player not found"}
+		})
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		defer p.Return(c, nil)
+
+		err = c.RemoveAdmin(ctx, "1")
+
+		Expect(errors.Is(err, rconv2.ErrBadRequest)).To(BeTrue())
+		Expect(errors.Is(err, rconv2.ErrPlayerNotFound)).To(BeFalse())
+		Expect(errors.Is(err, rconv2.ErrNotFound)).To(BeFalse())
+	})
+})
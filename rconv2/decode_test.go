@@ -0,0 +1,110 @@
+package rconv2_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/floriansw/go-hll-rcon/rconv2"
+	"github.com/floriansw/go-hll-rcon/rconv2/api"
+	"github.com/floriansw/go-hll-rcon/rconv2/rconv2test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Response decoding", func() {
+	var s *rconv2test.Server
+	var ctx context.Context
+
+	BeforeEach(func() {
+		var err error
+		s, err = rconv2test.NewServer(password)
+		Expect(err).ToNot(HaveOccurred())
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		Expect(s.Close()).To(Succeed())
+	})
+
+	withConnection := func(opts rconv2.ConnectionPoolOptions, f func(c *rconv2.Connection)) {
+		p := newPool(s, opts)
+		defer p.Shutdown(context.Background())
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		defer p.Return(c, nil)
+		f(c)
+	}
+
+	It("returns a DecodeError when the response does not match the response type", func() {
+		s.Respond("GetServerInformation", `{"players":"none"}`)
+
+		withConnection(rconv2.ConnectionPoolOptions{}, func(c *rconv2.Connection) {
+			res, err := c.Players(ctx)
+
+			Expect(res).To(BeNil())
+			var derr *rconv2.DecodeError
+			Expect(errors.As(err, &derr)).To(BeTrue())
+			Expect(derr.Command).To(Equal("GetServerInformation"))
+			Expect(derr.Content).To(Equal(`{"players":"none"}`))
+			Expect(rconv2.IsBrokenHllConnection(err)).To(BeFalse())
+		})
+	})
+
+	It("returns a DecodeError for an empty response", func() {
+		s.Respond("GetServerInformation", "")
+
+		withConnection(rconv2.ConnectionPoolOptions{}, func(c *rconv2.Connection) {
+			_, err := c.Players(ctx)
+
+			Expect(err).To(BeAssignableToTypeOf(&rconv2.DecodeError{}))
+		})
+	})
+
+	It("ignores unknown fields by default", func() {
+		s.Respond("GetServerInformation", `{"players":[],"newField":1}`)
+
+		withConnection(rconv2.ConnectionPoolOptions{}, func(c *rconv2.Connection) {
+			_, err := c.Players(ctx)
+
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	It("reports unknown fields with strict decoding", func() {
+		s.Respond("GetServerInformation", `{"players":[],"newField":1}`)
+
+		withConnection(rconv2.ConnectionPoolOptions{StrictDecoding: true}, func(c *rconv2.Connection) {
+			_, err := c.Players(ctx)
+
+			Expect(err).To(BeAssignableToTypeOf(&rconv2.DecodeError{}))
+			Expect(err.Error()).To(ContainSubstring("newField"))
+		})
+	})
+
+	It("provides the raw content next to the typed result", func() {
+		s.Respond("GetServerInformation", `{"players":[{"name":"Player"}]}`)
+
+		withConnection(rconv2.ConnectionPoolOptions{}, func(c *rconv2.Connection) {
+			res, raw, err := rconv2.CommandAsRaw[api.GetPlayersResponse](ctx, c, "GetServerInformation", api.GetServerInformation{
+				Name: api.ServerInformationNamePlayers,
+			})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Players).To(HaveLen(1))
+			Expect(raw).To(MatchJSON(`{"players":[{"name":"Player"}]}`))
+		})
+	})
+
+	It("provides the raw content of responses which could not be decoded", func() {
+		s.Respond("GetServerInformation", `{"players":"none"}`)
+
+		withConnection(rconv2.ConnectionPoolOptions{}, func(c *rconv2.Connection) {
+			_, raw, err := rconv2.CommandAsRaw[api.GetPlayersResponse](ctx, c, "GetServerInformation", api.GetServerInformation{
+				Name: api.ServerInformationNamePlayers,
+			})
+
+			Expect(err).To(BeAssignableToTypeOf(&rconv2.DecodeError{}))
+			Expect(raw).To(MatchJSON(`{"players":"none"}`))
+		})
+	})
+})
@@ -0,0 +1,273 @@
+package rconv2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// ErrUnknownServer is returned when a server is requested from a Fleet, which is not part of it.
+var ErrUnknownServer = errors.New("unknown server")
+
+// ServerOptions are the options of a single server of a Fleet.
+type ServerOptions struct {
+	// Hostname is the hostname/IP address of the Hell Let Loose server where it can be reached.
+	Hostname string
+	// Port is the Hell Let Loose RCon port of the server.
+	Port int
+	// Password is the RCon password of the Hell Let Loose server used to authenticate.
+	Password string
+}
+
+type FleetOptions struct {
+	// Pool are the options used for the ConnectionPool of each server. The Hostname, Port and Password are taken from
+	// the ServerOptions of the respective server. If a Logger is set, the pool of each server logs with the name of
+	// the server as the "server" attribute.
+	Pool ConnectionPoolOptions
+	// Servers are the servers of the Fleet by their name, which is used to refer to the server with the methods of
+	// the Fleet.
+	Servers map[string]ServerOptions
+	// Hooks optionally returns the PoolHooks for the ConnectionPool of the server with the given name, which allows to
+	// tell the servers apart, e.g. in metrics. If set, it replaces the Hooks of Pool.
+	Hooks func(server string) PoolHooks
+	// OnCircuitStateChange is an optional callback, which is called with the name of the server whenever the circuit
+	// breaker of its ConnectionPool changed its state. It is only called if Pool.CircuitBreaker is set, and replaces
+	// its OnStateChange callback. See CircuitBreakerPolicy.OnStateChange.
+	OnCircuitStateChange func(server string, from, to CircuitState)
+}
+
+// Fleet holds a ConnectionPool for each of multiple servers, which are referred to by a name. Servers can be added to
+// and removed from a Fleet at any time. A Fleet is safe for concurrent use by multiple goroutines.
+type Fleet struct {
+	opts          ConnectionPoolOptions
+	hooks         func(server string) PoolHooks
+	onStateChange func(server string, from, to CircuitState)
+
+	mu    sync.RWMutex
+	pools map[string]*ConnectionPool
+}
+
+// FleetFunc is called by ForEach and FanOut with a Connection to each server of a Fleet.
+type FleetFunc func(ctx context.Context, server string, c *Connection) error
+
+// FleetValueFunc is called by ForEachValues and FanOutValues with a Connection to each server of a Fleet. The value it
+// returns is collected for each server.
+type FleetValueFunc[T any] func(ctx context.Context, server string, c *Connection) (T, error)
+
+// FleetResults holds the error FleetFunc returned for each server by the name of the server. The error of servers
+// the function succeeded for is nil.
+type FleetResults map[string]error
+
+// Err returns an error joining the errors of all servers the function failed for, or nil, if it succeeded for all
+// servers.
+func (r FleetResults) Err() error {
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(r)) {
+		if r[name] != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, r[name]))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Result is the value and the error a FleetValueFunc returned for a single server.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// FleetValues holds the Result of a FleetValueFunc for each server by the name of the server.
+type FleetValues[T any] map[string]Result[T]
+
+// Err returns an error joining the errors of all servers the function failed for, or nil, if it succeeded for all
+// servers. See FleetResults.Err.
+func (r FleetValues[T]) Err() error {
+	return r.errors().Err()
+}
+
+func (r FleetValues[T]) errors() FleetResults {
+	res := FleetResults{}
+	for name, v := range r {
+		res[name] = v.Err
+	}
+	return res
+}
+
+// NewFleet creates a Fleet with a ConnectionPool for each of the Servers. If the pool of one server can not be
+// created, no Fleet is created.
+func NewFleet(opts FleetOptions) (*Fleet, error) {
+	f := &Fleet{
+		opts:          opts.Pool,
+		hooks:         opts.Hooks,
+		onStateChange: opts.OnCircuitStateChange,
+		pools:         map[string]*ConnectionPool{},
+	}
+	for name, s := range opts.Servers {
+		if err := f.Add(name, s); err != nil {
+			// no connection was handed out yet, the pools shut down immediately
+			_, _ = f.Shutdown(context.Background())
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Add adds the server with the given name to the Fleet. The name must not be used by another server of the Fleet.
+func (f *Fleet) Add(name string, s ServerOptions) error {
+	opts := f.opts
+	opts.Hostname = s.Hostname
+	opts.Port = s.Port
+	opts.Password = s.Password
+	if opts.Logger != nil {
+		opts.Logger = opts.Logger.With("server", name)
+	}
+	if f.hooks != nil {
+		opts.Hooks = f.hooks(name)
+	}
+	if opts.CircuitBreaker != nil && f.onStateChange != nil {
+		cb := *opts.CircuitBreaker
+		cb.OnStateChange = func(from, to CircuitState) {
+			f.onStateChange(name, from, to)
+		}
+		opts.CircuitBreaker = &cb
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.pools[name]; ok {
+		return fmt.Errorf("server %s is already part of the fleet", name)
+	}
+	p, err := NewConnectionPool(opts)
+	if err != nil {
+		return fmt.Errorf("server %s: %w", name, err)
+	}
+	f.pools[name] = p
+	return nil
+}
+
+// Remove removes the server with the given name from the Fleet and shuts down its ConnectionPool, see
+// ConnectionPool.Shutdown.
+func (f *Fleet) Remove(ctx context.Context, name string) error {
+	f.mu.Lock()
+	p, ok := f.pools[name]
+	delete(f.pools, name)
+	f.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownServer, name)
+	}
+	_, err := p.Shutdown(ctx)
+	return err
+}
+
+// On returns the ConnectionPool of the server with the given name.
+func (f *Fleet) On(name string) (*ConnectionPool, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	p, ok := f.pools[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownServer, name)
+	}
+	return p, nil
+}
+
+// Servers returns the names of all servers of the Fleet in alphabetical order.
+func (f *Fleet) Servers() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return slices.Sorted(maps.Keys(f.pools))
+}
+
+// ForEach calls fn with a Connection of each server, one server after another in alphabetical order of their names.
+// A failing server does not stop ForEach, all servers are visited unless ctx is done.
+func (f *Fleet) ForEach(ctx context.Context, fn FleetFunc) FleetResults {
+	return ForEachValues(ctx, f, withoutValue(fn)).errors()
+}
+
+// FanOut calls fn with a Connection of each server concurrently and waits for all calls to return.
+func (f *Fleet) FanOut(ctx context.Context, fn FleetFunc) FleetResults {
+	return FanOutValues(ctx, f, withoutValue(fn)).errors()
+}
+
+// ForEachValues calls fn with a Connection of each server of the Fleet the same way as Fleet.ForEach, and collects the
+// value fn returned for each server.
+func ForEachValues[T any](ctx context.Context, f *Fleet, fn FleetValueFunc[T]) FleetValues[T] {
+	res := FleetValues[T]{}
+	for _, name := range f.Servers() {
+		if err := ctx.Err(); err != nil {
+			res[name] = Result[T]{Err: err}
+			continue
+		}
+		res[name] = withServer(ctx, f, name, fn)
+	}
+	return res
+}
+
+// FanOutValues calls fn with a Connection of each server of the Fleet concurrently the same way as Fleet.FanOut, and
+// collects the value fn returned for each server.
+func FanOutValues[T any](ctx context.Context, f *Fleet, fn FleetValueFunc[T]) FleetValues[T] {
+	names := f.Servers()
+	results := make([]Result[T], len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = withServer(ctx, f, name, fn)
+		}()
+	}
+	wg.Wait()
+
+	res := FleetValues[T]{}
+	for i, name := range names {
+		res[name] = results[i]
+	}
+	return res
+}
+
+// Shutdown removes all servers from the Fleet and shuts down their ConnectionPool concurrently, see
+// ConnectionPool.Shutdown. It returns the total number of connections closed forcefully.
+func (f *Fleet) Shutdown(ctx context.Context) (forceClosed int, err error) {
+	f.mu.Lock()
+	pools := f.pools
+	f.pools = map[string]*ConnectionPool{}
+	f.mu.Unlock()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, p := range pools {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, perr := p.Shutdown(ctx)
+			mu.Lock()
+			defer mu.Unlock()
+			forceClosed += n
+			if perr != nil {
+				err = perr
+			}
+		}()
+	}
+	wg.Wait()
+	return forceClosed, err
+}
+
+func withServer[T any](ctx context.Context, f *Fleet, name string, fn FleetValueFunc[T]) (res Result[T]) {
+	p, err := f.On(name)
+	if err != nil {
+		// the server was removed in the meantime
+		return Result[T]{Err: err}
+	}
+	res.Err = p.WithConnection(ctx, func(c *Connection) (err error) {
+		res.Value, err = fn(ctx, name, c)
+		return err
+	})
+	return res
+}
+
+func withoutValue(fn FleetFunc) FleetValueFunc[struct{}] {
+	return func(ctx context.Context, server string, c *Connection) (struct{}, error) {
+		return struct{}{}, fn(ctx, server, c)
+	}
+}
@@ -0,0 +1,214 @@
+package rconv2_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/floriansw/go-hll-rcon/rconv2"
+	"github.com/floriansw/go-hll-rcon/rconv2/api"
+	"github.com/floriansw/go-hll-rcon/rconv2/rconv2test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fleet", func() {
+	var first, second *rconv2test.Server
+	var f *rconv2.Fleet
+	var ctx context.Context
+
+	serverOptions := func(s *rconv2test.Server) rconv2.ServerOptions {
+		return rconv2.ServerOptions{Hostname: s.Host(), Port: s.Port(), Password: password}
+	}
+
+	BeforeEach(func() {
+		var err error
+		first, err = rconv2test.NewServer(password)
+		Expect(err).ToNot(HaveOccurred())
+		first.Respond("GetServerInformation", api.GetServerConfigResponse{ServerName: "first"})
+		second, err = rconv2test.NewServer(password)
+		Expect(err).ToNot(HaveOccurred())
+		second.Respond("GetServerInformation", api.GetServerConfigResponse{ServerName: "second"})
+		ctx = context.Background()
+
+		f, err = rconv2.NewFleet(rconv2.FleetOptions{
+			Servers: map[string]rconv2.ServerOptions{
+				"first":  serverOptions(first),
+				"second": serverOptions(second),
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		f.Shutdown(context.Background())
+		Expect(first.Close()).To(Succeed())
+		Expect(second.Close()).To(Succeed())
+	})
+
+	It("returns the pool of a server by its name", func() {
+		p, err := f.On("second")
+		Expect(err).ToNot(HaveOccurred())
+
+		err = p.WithConnection(ctx, func(c *rconv2.Connection) error {
+			res, err := c.ServerConfig(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.ServerName).To(Equal("second"))
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = f.On("third")
+		Expect(errors.Is(err, rconv2.ErrUnknownServer)).To(BeTrue())
+	})
+
+	It("runs a function for each server", func() {
+		var names []string
+		res := f.ForEach(ctx, func(ctx context.Context, server string, c *rconv2.Connection) error {
+			cfg, err := c.ServerConfig(ctx)
+			if err != nil {
+				return err
+			}
+			names = append(names, cfg.ServerName)
+			return nil
+		})
+
+		Expect(res.Err()).ToNot(HaveOccurred())
+		Expect(res).To(HaveLen(2))
+		Expect(names).To(Equal([]string{"first", "second"}))
+	})
+
+	It("fans out a function to all servers and reports errors per server", func() {
+		second.Handle("GetServerInformation", func(r rconv2test.Request) rconv2test.Response {
+			return rconv2test.Response{StatusCode: 500, StatusMessage: "internal error"}
+		})
+		var mu sync.Mutex
+		names := map[string]string{}
+		res := f.FanOut(ctx, func(ctx context.Context, server string, c *rconv2.Connection) error {
+			cfg, err := c.ServerConfig(ctx)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			names[server] = cfg.ServerName
+			return nil
+		})
+
+		Expect(res["first"]).ToNot(HaveOccurred())
+		Expect(errors.Is(res["second"], rconv2.ErrServerError)).To(BeTrue())
+		Expect(res.Err()).To(MatchError(ContainSubstring("second: ")))
+		Expect(names).To(Equal(map[string]string{"first": "first"}))
+	})
+
+	It("collects the values of a function for each server", func() {
+		second.Handle("GetServerInformation", func(r rconv2test.Request) rconv2test.Response {
+			return rconv2test.Response{StatusCode: 500, StatusMessage: "internal error"}
+		})
+		serverName := func(ctx context.Context, server string, c *rconv2.Connection) (string, error) {
+			cfg, err := c.ServerConfig(ctx)
+			if err != nil {
+				return "", err
+			}
+			return cfg.ServerName, nil
+		}
+
+		for _, res := range []rconv2.FleetValues[string]{
+			rconv2.FanOutValues(ctx, f, serverName),
+			rconv2.ForEachValues(ctx, f, serverName),
+		} {
+			Expect(res).To(HaveLen(2))
+			Expect(res["first"].Err).ToNot(HaveOccurred())
+			Expect(res["first"].Value).To(Equal("first"))
+			Expect(errors.Is(res["second"].Err, rconv2.ErrServerError)).To(BeTrue())
+			Expect(res.Err()).To(MatchError(ContainSubstring("second: ")))
+		}
+	})
+
+	It("reports events of the pools with the name of the server", func() {
+		var mu sync.Mutex
+		var events []string
+		record := func(event string) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, event)
+		}
+		secondPort := ":" + strconv.Itoa(second.Port())
+		g, err := rconv2.NewFleet(rconv2.FleetOptions{
+			Pool: rconv2.ConnectionPoolOptions{
+				ReconnectPolicy: &rconv2.ReconnectPolicy{MaxAttempts: 1},
+				Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+					if strings.HasSuffix(address, secondPort) {
+						return nil, syscall.ECONNREFUSED
+					}
+					var d net.Dialer
+					return d.DialContext(ctx, network, address)
+				},
+				CircuitBreaker: &rconv2.CircuitBreakerPolicy{FailureThreshold: 1, InitialBackoff: time.Minute},
+			},
+			Servers: map[string]rconv2.ServerOptions{
+				"first":  serverOptions(first),
+				"second": serverOptions(second),
+			},
+			Hooks: func(server string) rconv2.PoolHooks {
+				return rconv2.PoolHooks{
+					OnAuthenticated: func(connectionId string, err error) {
+						record(server + ": authenticated")
+					},
+				}
+			},
+			OnCircuitStateChange: func(server string, from, to rconv2.CircuitState) {
+				record(server + ": " + from.String() + "->" + to.String())
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		defer g.Shutdown(ctx)
+
+		res := g.ForEach(ctx, func(ctx context.Context, server string, c *rconv2.Connection) error {
+			return nil
+		})
+
+		Expect(errors.Is(res["second"], syscall.ECONNREFUSED)).To(BeTrue())
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(events).To(Equal([]string{"first: authenticated", "second: closed->open"}))
+	})
+
+	It("adds and removes servers at runtime", func() {
+		third, err := rconv2test.NewServer(password)
+		Expect(err).ToNot(HaveOccurred())
+		defer third.Close()
+		third.Respond("GetServerInformation", map[string]any{})
+
+		Expect(f.Add("third", serverOptions(third))).To(Succeed())
+		Expect(f.Add("third", serverOptions(third))).ToNot(Succeed())
+		Expect(f.Remove(ctx, "first")).To(Succeed())
+		Expect(errors.Is(f.Remove(ctx, "first"), rconv2.ErrUnknownServer)).To(BeTrue())
+
+		Expect(f.Servers()).To(Equal([]string{"second", "third"}))
+		res := f.FanOut(ctx, func(ctx context.Context, server string, c *rconv2.Connection) error {
+			_, err := c.ServerConfig(ctx)
+			return err
+		})
+		Expect(res.Err()).ToNot(HaveOccurred())
+		Expect(res).To(HaveLen(2))
+	})
+
+	It("shuts down all pools", func() {
+		f.ForEach(ctx, func(ctx context.Context, server string, c *rconv2.Connection) error {
+			return nil
+		})
+		Expect(first.OpenConnections()).To(Equal(1))
+
+		f.Shutdown(context.Background())
+
+		Expect(f.Servers()).To(BeEmpty())
+		Eventually(first.OpenConnections).Should(Equal(0))
+		Eventually(second.OpenConnections).Should(Equal(0))
+	})
+})
@@ -0,0 +1,135 @@
+package rconv2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+const (
+	// defaultBufferSize is the initial size of pooled buffers, big enough for most responses (e.g. the player list of
+	// a full server) to not need to grow the buffer.
+	defaultBufferSize = 16 * 1024
+	// maxPooledBufferSize is the size up to which buffers are reused. Bigger buffers, e.g. for a huge admin log, are
+	// left to the garbage collector, so that a single big response does not increase the memory use permanently.
+	maxPooledBufferSize = 1024 * 1024
+)
+
+// buffers holds the buffers responses are read into. Polling the server repeatedly therefore does not allocate a new
+// buffer for each response.
+var buffers = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, defaultBufferSize)
+		return &b
+	},
+}
+
+func getBuffer() *[]byte {
+	return buffers.Get().(*[]byte)
+}
+
+// putBuffer returns a buffer obtained with getBuffer to the pool. The buffer must not be used afterward. A nil buffer
+// is ignored.
+func putBuffer(b *[]byte) {
+	if b == nil || cap(*b) > maxPooledBufferSize {
+		return
+	}
+	*b = (*b)[:0]
+	buffers.Put(b)
+}
+
+// contentOf returns the content of a buffer obtained with getBuffer, or nil for a nil buffer.
+func contentOf(b *[]byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return *b
+}
+
+// frameWriter encodes requests into frames, reusing the same buffer for each frame. A frameWriter must not be used
+// concurrently.
+type frameWriter struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+func newFrameWriter() *frameWriter {
+	w := &frameWriter{}
+	w.enc = json.NewEncoder(&w.buf)
+	return w
+}
+
+// encode returns the frame of the request with the given request ID: the header followed by the JSON encoded request,
+// XOR encoded with key. The returned slice is only valid until the next call to encode.
+func (w *frameWriter) encode(id uint32, req rawRequest, key []byte) ([]byte, error) {
+	if w.buf.Cap() > maxPooledBufferSize {
+		w.buf = bytes.Buffer{}
+	}
+	w.buf.Reset()
+	var header [headerSize]byte
+	w.buf.Write(header[:])
+	if err := w.enc.Encode(req); err != nil {
+		return nil, err
+	}
+	f := w.buf.Bytes()
+	// the encoder terminates each value with a newline, which is not part of the message
+	f = f[:len(f)-1]
+	binary.LittleEndian.PutUint32(f[0:4], magicNumber)
+	binary.LittleEndian.PutUint32(f[4:8], id)
+	binary.LittleEndian.PutUint32(f[8:12], uint32(len(f)-headerSize))
+	xor(f[headerSize:], key)
+	return f, nil
+}
+
+// readFrame reads the next message from r into buf, which is grown if needed. Messages with a content length greater
+// than maxSize are rejected. The returned content shares the underlying array with buf, if it was big enough.
+func readFrame(r io.Reader, maxSize uint32, buf []byte) (uint32, []byte, error) {
+	// each response has a fixed 12-byte header, the first 4 bytes is a magic number, followed by the response Id
+	// assigned by the server and the next 4 bytes is the content length of the response body
+	// byte format as used in python is: <III
+	header := grow(buf, headerSize)
+	_, err := io.ReadFull(r, header)
+	if err != nil {
+		return 0, nil, fmt.Errorf("read header failed: %w", err)
+	}
+	magic := binary.LittleEndian.Uint32(header[0:4])
+	responseId := binary.LittleEndian.Uint32(header[4:8])
+	contentLength := binary.LittleEndian.Uint32(header[8:12])
+	if magic != magicNumber {
+		return 0, nil, fmt.Errorf("%w: magic number does not match: expected %d to match %d", ErrProtocolDesync, magic, magicNumber)
+	}
+	if contentLength > maxSize {
+		return 0, nil, fmt.Errorf("%w: responseId: %d, content length %d exceeds maximum frame size of %d", ErrProtocolDesync, responseId, contentLength, maxSize)
+	}
+
+	answer := grow(header, int(contentLength))
+	l, err := io.ReadFull(r, answer)
+	if len(answer) != l {
+		return 0, nil, fmt.Errorf("%w responseId: %d, contentLength: %d, read: %d: %w", ErrReadLengthUnequal, responseId, contentLength, l, err)
+	}
+	return responseId, answer, nil
+}
+
+// grow returns a slice of length n, which reuses the underlying array of buf, if it is big enough.
+func grow(buf []byte, n int) []byte {
+	if cap(buf) < n {
+		return make([]byte, n)
+	}
+	return buf[:n]
+}
+
+// xor encodes (or decodes) the message in place with the key. A message is not changed with an empty key.
+func xor(msg, key []byte) {
+	if len(key) == 0 {
+		return
+	}
+	for i := 0; i < len(msg); i += len(key) {
+		block := msg[i:min(i+len(key), len(msg))]
+		for j := range block {
+			block[j] ^= key[j]
+		}
+	}
+}
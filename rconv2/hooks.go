@@ -0,0 +1,38 @@
+package rconv2
+
+// PoolHooks are optional callbacks for events in the lifecycle of the connections of a ConnectionPool, e.g. to collect
+// metrics or to alert on failures. Each callback receives the ID of the Connection the event belongs to, and the error
+// of the event, if any. Callbacks are called synchronously, without holding any lock of the pool, and must not block.
+type PoolHooks struct {
+	// OnConnectionOpened is called whenever a TCP connection to the server was established for a Connection,
+	// including reconnects of an existing Connection. err is always nil.
+	OnConnectionOpened func(connectionId string, err error)
+	// OnAuthenticated is called whenever a Connection logged in to the server successfully. err is always nil.
+	OnAuthenticated func(connectionId string, err error)
+	// OnAuthenticationFailed is called whenever a Connection failed to log in to the server, e.g. because of a wrong
+	// password (ErrInvalidCredentials).
+	OnAuthenticationFailed func(connectionId string, err error)
+	// OnReauthenticated is called whenever a Connection re-authenticated with the server, because the server did not
+	// accept the auth token of the Connection anymore (e.g. after a server restart). The command that failed because of
+	// the outdated auth token is retried once after the re-authentication. err is nil, if the re-authentication
+	// succeeded.
+	OnReauthenticated func(connectionId string, err error)
+	// OnReturned is called whenever a Connection handed out by the pool was returned with ConnectionPool.Return, with
+	// the error passed to Return.
+	OnReturned func(connectionId string, err error)
+	// OnRetiredBroken is called whenever a Connection was closed, because it was returned with an error indicating a
+	// broken connection (see IsBrokenHllConnection).
+	OnRetiredBroken func(connectionId string, err error)
+	// OnClosedIdle is called whenever a returned Connection was closed, because the pool already holds
+	// MaxIdleConnections idle connections. err is always nil.
+	OnClosedIdle func(connectionId string, err error)
+	// OnWaiterTimedOut is called whenever a request waiting for a connection with ConnectionPool.Get timed out. The
+	// connectionId is always empty, as no Connection was handed out.
+	OnWaiterTimedOut func(connectionId string, err error)
+}
+
+func call(hook func(connectionId string, err error), connectionId string, err error) {
+	if hook != nil {
+		hook(connectionId, err)
+	}
+}
@@ -0,0 +1,236 @@
+package rconv2_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/floriansw/go-hll-rcon/rconv2"
+	"github.com/floriansw/go-hll-rcon/rconv2/rconv2test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type event struct {
+	name         string
+	connectionId string
+	err          error
+}
+
+var _ = Describe("PoolHooks", func() {
+	var s *rconv2test.Server
+	var ctx context.Context
+	var mu sync.Mutex
+	var events []event
+	var hooks rconv2.PoolHooks
+
+	record := func(name string) func(connectionId string, err error) {
+		return func(connectionId string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, event{name: name, connectionId: connectionId, err: err})
+		}
+	}
+	recorded := func(name string) []event {
+		mu.Lock()
+		defer mu.Unlock()
+		var res []event
+		for _, e := range events {
+			if e.name == name {
+				res = append(res, e)
+			}
+		}
+		return res
+	}
+
+	BeforeEach(func() {
+		var err error
+		s, err = rconv2test.NewServer(password)
+		Expect(err).ToNot(HaveOccurred())
+		ctx = context.Background()
+		events = nil
+		hooks = rconv2.PoolHooks{
+			OnConnectionOpened:     record("opened"),
+			OnAuthenticated:        record("authenticated"),
+			OnAuthenticationFailed: record("authentication-failed"),
+			OnReturned:             record("returned"),
+			OnRetiredBroken:        record("retired-broken"),
+			OnClosedIdle:           record("closed-idle"),
+			OnWaiterTimedOut:       record("waiter-timed-out"),
+		}
+	})
+
+	AfterEach(func() {
+		Expect(s.Close()).To(Succeed())
+	})
+
+	It("reports the lifecycle of connections", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{MaxIdleConnections: ptr(1), Hooks: hooks})
+		defer p.Shutdown(ctx)
+		first, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		second, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(recorded("opened")).To(HaveLen(2))
+		Expect(recorded("authenticated")).To(HaveLen(2))
+		Expect(recorded("authenticated")[0].err).ToNot(HaveOccurred())
+
+		p.Return(first, nil)
+		p.Return(second, nil)
+		Expect(recorded("returned")).To(HaveLen(2))
+		Expect(recorded("closed-idle")).To(HaveLen(1))
+		Expect(recorded("closed-idle")[0].connectionId).ToNot(BeEmpty())
+
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		p.Return(c, rconv2.ErrProtocolDesync)
+		broken := recorded("retired-broken")
+		Expect(broken).To(HaveLen(1))
+		Expect(broken[0].connectionId).To(Equal(recorded("returned")[2].connectionId))
+		Expect(errors.Is(broken[0].err, rconv2.ErrProtocolDesync)).To(BeTrue())
+	})
+
+	It("calls the hooks without holding the lock of the pool", func() {
+		var p *rconv2.ConnectionPool
+		stats := func(connectionId string, err error) {
+			_ = p.Stats()
+		}
+		p = newPool(s, rconv2.ConnectionPoolOptions{
+			MaxIdleConnections: ptr(1),
+			Hooks: rconv2.PoolHooks{
+				OnConnectionOpened: stats,
+				OnAuthenticated:    stats,
+				OnReturned:         stats,
+				OnRetiredBroken:    stats,
+				OnClosedIdle:       stats,
+			},
+		})
+		defer p.Shutdown(ctx)
+
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer close(done)
+			first, err := p.Get(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			second, err := p.Get(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			p.Return(first, nil)
+			p.Return(second, rconv2.ErrProtocolDesync)
+		}()
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("does not report connections as returned, which were not handed out", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{MinIdleConnections: ptr(1), Hooks: hooks})
+		defer p.Shutdown(ctx)
+
+		Eventually(func() int { return p.Stats().Idle }).Should(Equal(1))
+		Expect(recorded("opened")).To(HaveLen(1))
+		Expect(recorded("returned")).To(BeEmpty())
+	})
+
+	It("reports failed authentications", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{Password: "wrong", Hooks: hooks})
+		defer p.Shutdown(ctx)
+
+		_, err := p.Get(ctx)
+
+		Expect(errors.Is(err, rconv2.ErrInvalidCredentials)).To(BeTrue())
+		failed := recorded("authentication-failed")
+		Expect(failed).To(HaveLen(1))
+		Expect(failed[0].connectionId).ToNot(BeEmpty())
+		Expect(errors.Is(failed[0].err, rconv2.ErrInvalidCredentials)).To(BeTrue())
+		Expect(recorded("authenticated")).To(BeEmpty())
+	})
+
+	It("does not report the probes of the circuit breaker", func() {
+		var down atomic.Bool
+		down.Store(true)
+		p := newPool(s, rconv2.ConnectionPoolOptions{
+			ReconnectPolicy: &rconv2.ReconnectPolicy{MaxAttempts: 1},
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				if down.Load() {
+					return nil, syscall.ECONNREFUSED
+				}
+				var d net.Dialer
+				return d.DialContext(ctx, network, address)
+			},
+			CircuitBreaker: &rconv2.CircuitBreakerPolicy{FailureThreshold: 1, InitialBackoff: 20 * time.Millisecond},
+			Hooks:          hooks,
+		})
+		defer p.Shutdown(ctx)
+
+		_, err := p.Get(ctx)
+		Expect(err).To(HaveOccurred())
+		Expect(p.CircuitState()).To(Equal(rconv2.CircuitStateOpen))
+		down.Store(false)
+
+		Eventually(p.CircuitState).Should(Equal(rconv2.CircuitStateClosed))
+		Expect(recorded("opened")).To(BeEmpty())
+		Expect(recorded("authenticated")).To(BeEmpty())
+	})
+
+	It("reports requests timed out waiting for a connection", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{
+			MaxOpenConnections: ptr(1),
+			MaxIdleConnections: ptr(1),
+			Hooks:              hooks,
+		})
+		defer p.Shutdown(ctx)
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		defer p.Return(c, nil)
+
+		tctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err = p.Get(tctx)
+
+		Expect(err).To(HaveOccurred())
+		timedOut := recorded("waiter-timed-out")
+		Expect(timedOut).To(HaveLen(1))
+		Expect(timedOut[0].connectionId).To(BeEmpty())
+		Expect(timedOut[0].err).To(MatchError(err))
+		Expect(recorded("returned")).To(BeEmpty())
+	})
+
+	It("does not report connections handed to a waiter which gave up as returned", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{
+			MaxOpenConnections: ptr(1),
+			MaxIdleConnections: ptr(1),
+			Hooks:              hooks,
+		})
+		defer p.Shutdown(ctx)
+
+		var returned atomic.Int32
+		for range 50 {
+			c, err := p.Get(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			wctx, cancel := context.WithCancel(ctx)
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+				w, err := p.Get(wctx)
+				if err == nil {
+					returned.Add(1)
+					p.Return(w, nil)
+				}
+			}()
+			Eventually(func() int { return p.Stats().Waiting }).Should(Equal(1))
+
+			// the connection might be handed to the waiter while it gives up
+			cancel()
+			returned.Add(1)
+			p.Return(c, nil)
+			Eventually(done).Should(BeClosed())
+		}
+
+		Expect(recorded("returned")).To(HaveLen(int(returned.Load())))
+	})
+})
@@ -0,0 +1,52 @@
+package rconv2
+
+import (
+	"context"
+	"time"
+)
+
+// CommandCall is a command a Connection is about to send to the server.
+type CommandCall struct {
+	// ConnectionId identifies the Connection the command is sent with.
+	ConnectionId string
+	// Name is the name of the command, e.g. GetServerInformation.
+	Name string
+	// Body is the request of the command, usually one of the request types of the api package. A CommandInterceptor
+	// may replace the Body, the command is sent with the Body as it is after all interceptors ran.
+	Body any
+}
+
+// CommandResult is the response of the server to a CommandCall.
+type CommandResult struct {
+	StatusCode    int
+	StatusMessage string
+	// Content is the contentBody of the response as sent by the server, usually JSON encoded.
+	Content string
+	// Duration is the time it took to send the command and receive the response.
+	Duration time.Duration
+}
+
+// CommandInvoker sends a CommandCall to the server, or passes it on to the next CommandInterceptor.
+type CommandInvoker func(ctx context.Context, call *CommandCall) (*CommandResult, error)
+
+// CommandInterceptor is run around each command sent by a Connection. It receives the command about to be sent and
+// the next CommandInvoker in the chain, which needs to be called to send the command to the server.
+//
+// An interceptor can inspect and modify the CommandCall before calling next, as well as the CommandResult (or error)
+// returned by next. It can also short-circuit the chain by returning a CommandResult or an error without calling next
+// at all, e.g. to implement a dry-run mode or permission checks. A CommandResult with a status code other than 200 is
+// returned to the caller of the command as UnexpectedStatus.
+//
+// The handshake with the server (ServerConnect and Login) is not run through interceptors.
+type CommandInterceptor func(ctx context.Context, call *CommandCall, next CommandInvoker) (*CommandResult, error)
+
+// chain wraps final with the interceptors, so that the first interceptor is run first.
+func chain(interceptors []CommandInterceptor, final CommandInvoker) CommandInvoker {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		ic, next := interceptors[i], final
+		final = func(ctx context.Context, call *CommandCall) (*CommandResult, error) {
+			return ic(ctx, call, next)
+		}
+	}
+	return final
+}
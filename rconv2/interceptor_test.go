@@ -0,0 +1,95 @@
+package rconv2_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/floriansw/go-hll-rcon/rconv2"
+	"github.com/floriansw/go-hll-rcon/rconv2/api"
+	"github.com/floriansw/go-hll-rcon/rconv2/rconv2test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CommandInterceptor", func() {
+	var s *rconv2test.Server
+	var ctx context.Context
+
+	BeforeEach(func() {
+		var err error
+		s, err = rconv2test.NewServer(password)
+		Expect(err).ToNot(HaveOccurred())
+		s.Respond("KickPlayer", nil)
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		Expect(s.Close()).To(Succeed())
+	})
+
+	It("runs interceptors in order around each command", func() {
+		var calls []string
+		record := func(name string) rconv2.CommandInterceptor {
+			return func(ctx context.Context, call *rconv2.CommandCall, next rconv2.CommandInvoker) (*rconv2.CommandResult, error) {
+				calls = append(calls, name+":"+call.Name)
+				res, err := next(ctx, call)
+				calls = append(calls, name+":done")
+				return res, err
+			}
+		}
+		p := newPool(s, rconv2.ConnectionPoolOptions{
+			Interceptors: []rconv2.CommandInterceptor{record("first"), record("second")},
+		})
+		defer p.Shutdown(context.Background())
+
+		err := p.WithConnection(ctx, func(c *rconv2.Connection) error {
+			return c.KickPlayer(ctx, "1", "reason")
+		})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(calls).To(Equal([]string{"first:KickPlayer", "second:KickPlayer", "second:done", "first:done"}))
+	})
+
+	It("allows modifying the command", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{
+			Interceptors: []rconv2.CommandInterceptor{
+				func(ctx context.Context, call *rconv2.CommandCall, next rconv2.CommandInvoker) (*rconv2.CommandResult, error) {
+					k := call.Body.(api.KickPlayer)
+					k.Reason = "[bot] " + k.Reason
+					call.Body = k
+					return next(ctx, call)
+				},
+			},
+		})
+		defer p.Shutdown(context.Background())
+
+		err := p.WithConnection(ctx, func(c *rconv2.Connection) error {
+			return c.KickPlayer(ctx, "1", "reason")
+		})
+
+		Expect(err).ToNot(HaveOccurred())
+		var req api.KickPlayer
+		Expect(s.RequestsFor("KickPlayer")[0].Bind(&req)).To(Succeed())
+		Expect(req.Reason).To(Equal("[bot] reason"))
+	})
+
+	It("allows short-circuiting the command", func() {
+		denied := errors.New("permission denied")
+		p := newPool(s, rconv2.ConnectionPoolOptions{
+			Interceptors: []rconv2.CommandInterceptor{
+				func(ctx context.Context, call *rconv2.CommandCall, next rconv2.CommandInvoker) (*rconv2.CommandResult, error) {
+					return nil, denied
+				},
+			},
+		})
+		defer p.Shutdown(context.Background())
+
+		err := p.WithConnection(ctx, func(c *rconv2.Connection) error {
+			Expect(c.KickPlayer(ctx, "1", "reason")).To(MatchError(denied))
+			return nil
+		})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.RequestsFor("KickPlayer")).To(BeEmpty())
+	})
+})
@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"math"
 	"os"
+	"slices"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -14,6 +17,11 @@ import (
 
 var (
 	defaultTimeout = 5 * time.Second
+	// defaultMaintenanceInterval is the interval in which the pool opens new connections to satisfy
+	// MinIdleConnections, if no other option defines a shorter interval.
+	defaultMaintenanceInterval = 30 * time.Second
+	// minMaintenanceInterval prevents very short durations from keeping the pool busy all the time.
+	minMaintenanceInterval = 10 * time.Millisecond
 )
 
 type ConnectionPoolOptions struct {
@@ -23,13 +31,18 @@ type ConnectionPoolOptions struct {
 	// no messages will be seen from the pool.
 	Logger *slog.Logger
 	// Hostname is the hostname/IP address of the Hell Let Loose server where it can be reached.
-	// Only IPv4 addresses are supported.
+	// IPv4 and IPv6 addresses are supported, as well as DNS names. A DNS name is resolved each time a connection to
+	// the server is established.
 	Hostname string
 	// Port is the Hell Let Loose RCon port of the server. The RCon port can usually be found in the Game Service Provider's
 	// server management console.
 	Port int
 	// Password is the RCon password of the Hell Let Loose server used to authenticate.
 	Password string
+	// Dial is an optional function used to open the TCP connections to the server, e.g. to connect through a proxy.
+	// It is called with the "tcp" network and the Hostname and Port joined to an address. If nil, a net.Dialer is
+	// used.
+	Dial DialFunc
 
 	// MaxOpenConnections is the maximum number of open connections the pool can not exceed. A request for a connection
 	// when the pool reached this size (and no idle connections are available) will be put into a queue and be served
@@ -43,12 +56,68 @@ type ConnectionPoolOptions struct {
 	// idle connection to benefit from re-using connections as much as possible.
 	// MaxIdleConnections cannot be greater than MaxOpenConnections
 	MaxIdleConnections *int
+	// MinIdleConnections is the number of idle connections the pool keeps open at least, as long as MaxOpenConnections
+	// is not reached. The connections are opened in the background right after the pool was created and whenever the
+	// number of idle connections dropped below MinIdleConnections, e.g. because connections expired.
+	// MinIdleConnections cannot be greater than MaxIdleConnections. Defaults to 0.
+	MinIdleConnections *int
+	// ReservedConnections is the number of connections of MaxOpenConnections, which can only be used by requests with
+	// PriorityHigh (see GetWithPriority). Requests with PriorityNormal wait for a connection, once all other connections
+	// are in use, which keeps the pool responsive for important commands, e.g. moderation actions, even if it is
+	// saturated by polling. ReservedConnections must be lower than MaxOpenConnections. Defaults to 0.
+	ReservedConnections *int
+	// MaxConnectionLifetime is the maximum time a connection may be reused since it was opened. Expired connections
+	// are closed when they are idle, a connection in use is closed once it is returned to the pool. 0 means
+	// connections are reused forever.
+	MaxConnectionLifetime time.Duration
+	// MaxIdleTime is the maximum time a connection may be idle in the pool before it is closed. 0 means connections
+	// are not closed because of being idle.
+	MaxIdleTime time.Duration
+	// HealthCheckInterval is the interval in which idle connections are checked with a cheap read command. Connections
+	// failing the check are closed, so that Get does not hand out connections the server dropped in the meantime.
+	// 0 disables the health check. Regardless of the health check, idle connections the server closed are never
+	// handed out by Get.
+	HealthCheckInterval time.Duration
+	// ReconnectPolicy controls how often and with which delay connections to the server are attempted, both when a new
+	// Connection is opened and when a Connection reconnects after the server closed the TCP connection.
+	// If nil, the defaults described in ReconnectPolicy are used.
+	ReconnectPolicy *ReconnectPolicy
+	// Recorder is an optional Recorder, which captures every request sent to the server together with its response.
+	// All Connections of the pool share the same Recorder.
+	Recorder *Recorder
+	// MaxFrameSize is the maximum size in bytes of a single response the server is allowed to send. A Connection
+	// receiving a bigger response fails with ErrProtocolDesync. Defaults to 16 MiB.
+	MaxFrameSize *int
+	// Interceptors are run, in the given order, around each command sent by a Connection of the pool. See
+	// CommandInterceptor.
+	Interceptors []CommandInterceptor
+	// RateLimit optionally limits the rate of commands sent by all Connections of the pool, with a separate budget
+	// for each CommandCategory. Commands are rate limited after all Interceptors ran, which allows Interceptors to
+	// observe RateLimitExceeded errors. If nil, commands are not rate limited.
+	RateLimit *RateLimitOptions
+	// StrictDecoding makes commands fail with a DecodeError, if the response of the server contains fields unknown to
+	// the response type of the command. This helps to detect changes of the game's API early. By default, unknown
+	// fields are ignored.
+	StrictDecoding bool
+	// RetryBrokenConnections makes WithConnection call its function once more with another Connection, if the function
+	// failed with an error indicating a broken connection (see IsBrokenHllConnection). The function is only retried,
+	// if all commands it sent are idempotent reads (see IsRetryable), as other commands might have been executed by
+	// the server already. The function must therefore not have other side effects, which prevent running it twice.
+	RetryBrokenConnections bool
+	// CircuitBreaker optionally makes the pool stop connecting to the server after consecutive connection failures,
+	// e.g. while the server is down. See CircuitBreakerPolicy. If nil, each request for a new connection tries to
+	// connect to the server.
+	CircuitBreaker *CircuitBreakerPolicy
+	// Hooks are optional callbacks for lifecycle events of the connections of the pool, see PoolHooks.
+	Hooks PoolHooks
 }
 
 func NewConnectionPool(opts ConnectionPoolOptions) (*ConnectionPool, error) {
 	if opts.Logger == nil {
 		opts.Logger = slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError}))
 	}
+	// IPv6 addresses might be passed in the bracketed notation used in URLs
+	opts.Hostname = strings.TrimSuffix(strings.TrimPrefix(opts.Hostname, "["), "]")
 	if opts.Hostname == "" {
 		return nil, errors.New("hostname cannot be an empty string")
 	}
@@ -61,42 +130,125 @@ func NewConnectionPool(opts ConnectionPoolOptions) (*ConnectionPool, error) {
 	if toInt(opts.MaxIdleConnections) == 0 {
 		opts.MaxIdleConnections = fromInt(10)
 	}
+	if toInt(opts.MaxFrameSize) < 0 || int64(toInt(opts.MaxFrameSize)) > math.MaxUint32 {
+		return nil, errors.New("the MaxFrameSize must be a positive integer lower than 4 GiB")
+	}
 	if toInt(opts.MaxIdleConnections) > toInt(opts.MaxOpenConnections) {
 		return nil, errors.New("the MaxIdleConnections cannot exceed MaxOpenConnections")
 	}
-	return &ConnectionPool{
-		logger:       opts.Logger,
-		host:         opts.Hostname,
-		port:         opts.Port,
-		pw:           opts.Password,
-		mu:           sync.Mutex{},
-		idles:        map[string]*Connection{},
-		maxOpenCount: toInt(opts.MaxOpenConnections),
-		maxIdleCount: toInt(opts.MaxIdleConnections),
-	}, nil
+	if toInt(opts.MinIdleConnections) < 0 || toInt(opts.MinIdleConnections) > toInt(opts.MaxIdleConnections) {
+		return nil, errors.New("the MinIdleConnections must be a positive integer and cannot exceed MaxIdleConnections")
+	}
+	if toInt(opts.ReservedConnections) < 0 || toInt(opts.ReservedConnections) >= toInt(opts.MaxOpenConnections) {
+		return nil, errors.New("the ReservedConnections must be a positive integer lower than MaxOpenConnections")
+	}
+	if opts.MaxConnectionLifetime < 0 || opts.MaxIdleTime < 0 || opts.HealthCheckInterval < 0 {
+		return nil, errors.New("the MaxConnectionLifetime, MaxIdleTime and HealthCheckInterval cannot be negative")
+	}
+	stats := &poolStats{}
+	// the statistics are collected first, so that they include commands rejected by other interceptors
+	interceptors := append([]CommandInterceptor{stats.intercept}, opts.Interceptors...)
+	if opts.RateLimit != nil {
+		l, err := newRateLimiter(*opts.RateLimit)
+		if err != nil {
+			return nil, err
+		}
+		interceptors = append(interceptors, l.intercept)
+	}
+	p := &ConnectionPool{
+		logger:        opts.Logger,
+		host:          opts.Hostname,
+		port:          opts.Port,
+		pw:            opts.Password,
+		dial:          opts.Dial,
+		mu:            sync.Mutex{},
+		maxOpenCount:  toInt(opts.MaxOpenConnections),
+		maxIdleCount:  toInt(opts.MaxIdleConnections),
+		reconnect:     opts.ReconnectPolicy.withDefaults(),
+		recorder:      opts.Recorder,
+		maxFrameSize:  uint32(toInt(opts.MaxFrameSize)),
+		interceptors:  interceptors,
+		strict:        opts.StrictDecoding,
+		retryBroken:   opts.RetryBrokenConnections,
+		hooks:         opts.Hooks,
+		minIdleCount:  toInt(opts.MinIdleConnections),
+		reservedCount: toInt(opts.ReservedConnections),
+		maxLifetime:   opts.MaxConnectionLifetime,
+		maxIdleTime:   opts.MaxIdleTime,
+		healthCheck:   opts.HealthCheckInterval,
+		closed:        make(chan struct{}),
+		released:      make(chan struct{}, 1),
+		conns:         map[*Connection]struct{}{},
+		stats:         stats,
+	}
+	p.breaker = newCircuitBreaker(opts.CircuitBreaker, p.probe)
+	if i := p.maintenanceInterval(); i > 0 {
+		go p.maintain(i)
+	}
+	return p, nil
 }
 
 type ConnectionPool struct {
-	logger       *slog.Logger
-	host         string
-	port         int
-	pw           string
-	mu           sync.Mutex
-	idles        map[string]*Connection
+	logger *slog.Logger
+	host   string
+	port   int
+	pw     string
+	dial   DialFunc
+	mu     sync.Mutex
+	// idles are the idle connections, the most recently returned one last
+	idles []*Connection
+	// conns are all open connections, idle or in use
+	conns        map[*Connection]struct{}
 	numOpen      int
 	maxOpenCount int
 	maxIdleCount int
-	queued       []request
+	// queued are the Get requests waiting for a connection, in the order they were made
+	queued       []*request
+	reconnect    ReconnectPolicy
+	recorder     *Recorder
+	maxFrameSize uint32
+	interceptors []CommandInterceptor
+	strict       bool
+	retryBroken  bool
+	hooks        PoolHooks
+	minIdleCount int
+	// reservedCount is the number of connections reserved for requests with PriorityHigh
+	reservedCount int
+	// numInUse is the number of connections handed out and not returned yet
+	numInUse int
+	// numNormal is the number of connections in use by requests with PriorityNormal
+	numNormal   int
+	maxLifetime time.Duration
+	maxIdleTime time.Duration
+	healthCheck time.Duration
+	// closed is closed once the pool was shut down
+	closed       chan struct{}
+	shutdownOnce sync.Once
+	// released is signalled whenever a connection was closed after the pool was shut down
+	released chan struct{}
+	stats    *poolStats
+	breaker  *circuitBreaker
 }
 
+// request is a Get request waiting for a connection. Exactly one connection or error is sent to a request, and only
+// while it is queued. Both channels are buffered, so that sending never blocks, even if the request was cancelled in
+// the meantime.
 type request struct {
+	priority Priority
 	connChan chan *Connection
 	errChan  chan error
 }
 
+// IsBrokenHllConnection reports whether err indicates that the Connection it was returned from should not be used
+// anymore. This includes commands aborted by their context.Context, as the command might have been sent to the
+// server partially only.
 func IsBrokenHllConnection(err error) bool {
 	return err != nil &&
 		(os.IsTimeout(err) ||
+			errors.Is(err, ErrCommandAborted) ||
+			errors.Is(err, ReconnectTriesExceeded) ||
+			errors.Is(err, ErrProtocolDesync) ||
+			errors.Is(err, ErrConnectionLost) ||
 			errors.Is(err, syscall.ECONNRESET) ||
 			errors.Is(err, syscall.ECONNREFUSED) ||
 			errors.Is(err, syscall.EPIPE))
@@ -106,27 +258,51 @@ func IsBrokenHllConnection(err error) bool {
 // might either be closed, put into a pool of "hot", idle connections or directly returned to a queued Get
 // request.
 func (p *ConnectionPool) Return(c *Connection, err error) {
+	call(p.hooks.OnReturned, c.id, err)
+	p.put(c, err, time.Now())
+}
+
+// put returns the connection to the pool like Return. If the connection is put into the pool of idle connections, it
+// is considered idle since idleSince.
+func (p *ConnectionPool) put(c *Connection, err error, idleSince time.Time) {
 	l := p.logger.With("action", "return", "id", c.id)
 	l.Debug("wait-for-lock")
+	// the hooks are called once the lock was released
+	var hook func(connectionId string, err error)
+	defer func() { call(hook, c.id, err) }()
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	if c.inUse {
+		c.inUse = false
+		p.numInUse--
+	}
+	if c.normal {
+		c.normal = false
+		p.numNormal--
+	}
 	if IsBrokenHllConnection(err) {
 		l.Debug("retire-broken", "error", err)
-		c.socket.Close()
-		p.numOpen--
-	} else if len(p.queued) != 0 {
-		r := p.queued[0]
+		p.retire(c, closeBroken)
+		hook = p.hooks.OnRetiredBroken
+	} else if p.expired(c, time.Now()) {
+		l.Debug("retire-expired")
+		p.retire(c, closeMaxLifetime)
+	} else if r := p.dequeueNext(); r != nil {
 		l.Debug("re-using-for-queue")
-		p.queued = p.queued[1:]
-		r.connChan <- c
-	} else if p.maxIdleCount > len(p.idles) {
+		r.connChan <- p.acquire(c, r.priority)
+	} else if p.maxIdleCount > len(p.idles) && !p.isClosed() {
 		l.Debug("returning-idle")
-		p.idles[c.id] = c
+		c.idleSince = idleSince
+		p.idles = append(p.idles, c)
+	} else if p.isClosed() {
+		l.Debug("closing-after-shutdown")
+		p.retire(c, closeShutdown)
 	} else {
 		l.Debug("closing")
-		c.socket.Close()
-		p.numOpen--
+		p.retire(c, closeMaxIdle)
+		hook = p.hooks.OnClosedIdle
+		err = nil
 	}
 }
 
@@ -135,100 +311,496 @@ func (p *ConnectionPool) Return(c *Connection, err error) {
 // returned to the pool just now.
 //
 // If there are no idle connections and if the limit of open connections is already reached, the request to retrieve a
-// Connection will be queued. The request might or might not be fulfilled later, once a Connection is returned to the
-// pool.
+// Connection will be queued. Queued requests are fulfilled in the order they were made, once a Connection is returned
+// to the pool or a new one can be opened. Get requests a Connection with PriorityNormal, see GetWithPriority.
+//
+// Once the pool was shut down, Get returns ErrPoolClosed.
 //
 // It is recommended to provide a context.Context with a deadline. The deadline will be the maximum time the caller is
 // ok with waiting for a connection before a Timeout error is returned. If no deadline is provided in the context.Context,
-// Get might wait indefinitely.
+// Get waits for 5 seconds at most. If the context.Context is cancelled while waiting, Get returns the error of the
+// context.
 func (p *ConnectionPool) Get(ctx context.Context) (*Connection, error) {
+	return p.GetWithPriority(ctx, PriorityNormal)
+}
+
+// GetWithPriority returns a connection from the pool like Get, but with the given Priority. Requests with PriorityHigh
+// are fulfilled before all requests with PriorityNormal, and can use the ReservedConnections.
+func (p *ConnectionPool) GetWithPriority(ctx context.Context, prio Priority) (*Connection, error) {
 	deadline, ok := ctx.Deadline()
-	l := p.logger.With("action", "get-with-context", "deadline", deadline, "hasDeadline", ok, "queued", len(p.queued), "open", p.numOpen, "idles", len(p.idles))
+	l := p.logger.With("action", "get-with-context", "deadline", deadline, "hasDeadline", ok)
 	l.Debug("wait-for-lock")
 	p.mu.Lock()
+	l = l.With("queued", len(p.queued), "open", p.numOpen, "idles", len(p.idles))
 
-	if len(p.idles) > 0 {
-		defer p.mu.Unlock()
-		l.Debug("from-idle-pool")
-		for _, c := range p.idles {
-			delete(p.idles, c.id)
-			return c, nil
+	if p.isClosed() {
+		p.mu.Unlock()
+		return nil, ErrPoolClosed
+	}
+	if p.allowed(prio) {
+		if c := p.popIdle(); c != nil {
+			defer p.mu.Unlock()
+			l.Debug("from-idle-pool")
+			return p.acquire(c, prio), nil
 		}
 	}
 
-	if p.numOpen >= p.maxOpenCount {
-		l.Debug("queue-request", "queued", len(p.queued), "open", p.numOpen)
-		req := request{
+	if p.numOpen >= p.maxOpenCount || !p.allowed(prio) {
+		l.Debug("queue-request", "queued", len(p.queued), "open", p.numOpen, "priority", prio)
+		req := &request{
+			priority: prio,
 			connChan: make(chan *Connection, 1),
 			errChan:  make(chan error, 1),
 		}
 
-		p.queued = append(p.queued, req)
+		p.enqueue(req)
+		numOpen := p.numOpen
 		p.mu.Unlock()
 
-		timeout := defaultTimeout
-		if d, o := ctx.Deadline(); o {
-			timeout = time.Until(d)
-		}
-
-		select {
-		case con := <-req.connChan:
-			return con, nil
-		case err := <-req.errChan:
-			return nil, err
-		case <-time.After(timeout):
-			return nil, newConnectionRequestTimeout(p.numOpen)
+		if !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, defaultTimeout)
+			defer cancel()
 		}
+		started := time.Now()
+		defer func() { p.stats.waited(time.Since(started)) }()
+		return p.wait(ctx, req, numOpen)
 	}
 
 	l.Debug("open-new", "queued", len(p.queued), "open", p.numOpen)
+	// the slot is taken before the lock is released, as opening the connection might take a while according to the
+	// ReconnectPolicy
 	p.numOpen++
-	defer p.mu.Unlock()
+	if prio == PriorityNormal {
+		p.numNormal++
+	}
+	p.mu.Unlock()
 
 	nc, err := p.new(ctx)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if prio == PriorityNormal {
+		p.numNormal--
+	}
 	if err != nil {
-		p.numOpen--
+		p.release()
 		return nil, err
 	}
+	p.conns[nc] = struct{}{}
+	if p.isClosed() {
+		l.Debug("closing-after-shutdown")
+		p.retire(nc, closeShutdown)
+		return nil, ErrPoolClosed
+	}
+	return p.acquire(nc, prio), nil
+}
 
-	return nc, nil
+// wait waits for the queued request to be fulfilled. If the context.Context is done first, the request is removed from
+// the queue. A connection handed to the request in the meantime is returned to the pool, so that it is not lost.
+func (p *ConnectionPool) wait(ctx context.Context, req *request, numOpen int) (*Connection, error) {
+	select {
+	case con := <-req.connChan:
+		return con, nil
+	case err := <-req.errChan:
+		return nil, err
+	case <-ctx.Done():
+	}
+
+	p.mu.Lock()
+	i := slices.Index(p.queued, req)
+	if i != -1 {
+		p.queued = slices.Delete(p.queued, i, i+1)
+	}
+	p.mu.Unlock()
+	if i == -1 {
+		// the request was fulfilled concurrently, exactly one of the channels holds the result
+		select {
+		case con := <-req.connChan:
+			p.put(con, nil, time.Now())
+		case <-req.errChan:
+		}
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err := newConnectionRequestTimeout(numOpen)
+		call(p.hooks.OnWaiterTimedOut, "", err)
+		return nil, err
+	}
+	return nil, ctx.Err()
+}
+
+// openForQueued opens a new connection for the next queued request, if the limit of open connections allows it. This
+// is needed when a connection is closed instead of being handed to a queued request. p.mu must be held.
+func (p *ConnectionPool) openForQueued() {
+	if p.nextQueued() == -1 || p.numOpen >= p.maxOpenCount || p.isClosed() {
+		return
+	}
+	p.numOpen++
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+		defer cancel()
+		c, err := p.new(ctx)
+		if err == nil {
+			p.mu.Lock()
+			p.conns[c] = struct{}{}
+			p.mu.Unlock()
+			p.put(c, nil, time.Now())
+			return
+		}
+		p.logger.Debug("open-for-queue-failed", "error", err)
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if r := p.dequeueNext(); r != nil {
+			r.errChan <- err
+		}
+		p.release()
+	}()
 }
 
 // WithConnection gathers a connection from the pool, if available, and executes the passed in function f.
 // Once the function returns, the connection is correctly returned to the pool with the error returned from f to ensure
-// the connection is not kept.
+// the connection is not kept, if it is broken. The error returned from f is returned as is.
+//
+// If RetryBrokenConnections is enabled, f is called once more with another Connection, if it failed because of a broken
+// connection and only sent idempotent reads.
 //
 // This is a helper to reduce the possibility a connection is obtained from the pool, but then not returned to it. It
 // is basically the same as using Get and Return in your own code.
 func (p *ConnectionPool) WithConnection(ctx context.Context, f func(c *Connection) error) error {
-	c, err := p.Get(ctx)
-	if err != nil {
+	return p.WithConnectionPriority(ctx, PriorityNormal, f)
+}
+
+// WithConnectionPriority executes the passed in function f with a connection from the pool like WithConnection, but
+// requests the connection with the given Priority, see GetWithPriority.
+func (p *ConnectionPool) WithConnectionPriority(ctx context.Context, prio Priority, f func(c *Connection) error) error {
+	retryable, err := p.withConnection(ctx, prio, f)
+	if err == nil || !retryable || !p.retryBroken || !IsBrokenHllConnection(err) || ctx.Err() != nil {
 		return err
 	}
-	var cerr error
-	defer p.Return(c, cerr)
+	p.logger.Debug("retry-broken", "error", err)
+	_, err = p.withConnection(ctx, prio, f)
+	return err
+}
 
-	cerr = f(c)
-	return nil
+// withConnection runs f with a Connection from the pool. It reports whether f only sent retryable commands.
+func (p *ConnectionPool) withConnection(ctx context.Context, prio Priority, f func(c *Connection) error) (retryable bool, err error) {
+	c, err := p.GetWithPriority(ctx, prio)
+	if err != nil {
+		return false, err
+	}
+	defer func() { p.Return(c, err) }()
+
+	c.mutated.Store(false)
+	err = f(c)
+	return !c.mutated.Load(), err
 }
 
 func (p *ConnectionPool) new(ctx context.Context) (*Connection, error) {
-	c, err := newSocket(ctx, p.host, p.port, p.pw)
+	if err := p.breaker.allow(); err != nil {
+		return nil, err
+	}
+	id := fmt.Sprintf("%d", time.Now().UnixNano())
+	c, err := p.openSocket(ctx, id, p.reconnect)
+	p.breaker.record(err)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Connection{
-		id:     fmt.Sprintf("%d", time.Now().UnixNano()),
-		socket: c,
-	}, nil
+	con := &Connection{
+		id:        id,
+		socket:    c,
+		strict:    p.strict,
+		createdAt: time.Now(),
+	}
+	con.invoke = chain(p.interceptors, con.send)
+	p.stats.opened.Add(1)
+	return con, nil
+}
+
+func (p *ConnectionPool) openSocket(ctx context.Context, id string, reconnect ReconnectPolicy) (*socket, error) {
+	opts := p.socketOptions(reconnect)
+	opts.onConnect = func() {
+		call(p.hooks.OnConnectionOpened, id, nil)
+	}
+	opts.onLogin = func(err error) {
+		if err != nil {
+			call(p.hooks.OnAuthenticationFailed, id, err)
+		} else {
+			call(p.hooks.OnAuthenticated, id, nil)
+		}
+	}
+	opts.onReauthenticate = func(err error) {
+		p.logger.Debug("reauthenticated", "id", id, "error", err)
+		call(p.hooks.OnReauthenticated, id, err)
+	}
+	return newSocket(ctx, opts)
+}
+
+// socketOptions returns the options of the sockets opened by the pool, without any PoolHooks.
+func (p *ConnectionPool) socketOptions(reconnect ReconnectPolicy) socketOptions {
+	return socketOptions{
+		host:         p.host,
+		port:         p.port,
+		pw:           p.pw,
+		dial:         p.dial,
+		reconnect:    reconnect,
+		recorder:     p.recorder,
+		maxFrameSize: p.maxFrameSize,
+	}
+}
+
+// probe opens and closes a connection with a single attempt, to check whether the server is reachable again. Probes
+// are not connections of the pool, hence they are not reported to the PoolHooks.
+func (p *ConnectionPool) probe(ctx context.Context) error {
+	r := p.reconnect
+	r.MaxAttempts = 1
+	s, err := newSocket(ctx, p.socketOptions(r))
+	p.logger.Debug("circuit-breaker-probe", "error", err)
+	if err != nil {
+		return err
+	}
+	return s.Close()
+}
+
+// CircuitState returns the state of the circuit breaker of the pool. It is always CircuitStateClosed, if no
+// CircuitBreaker is configured.
+func (p *ConnectionPool) CircuitState() CircuitState {
+	return p.breaker.current()
 }
 
-func (p *ConnectionPool) Shutdown() {
+// Shutdown closes the pool. Get fails with ErrPoolClosed afterward, and requests waiting for a connection fail with
+// ErrPoolClosed right away. Idle connections are closed immediately, connections in use are closed once they are
+// returned to the pool.
+//
+// Shutdown waits until all connections in use were returned, or until ctx is done. In the latter case, the connections
+// still in use are closed forcefully, which fails the commands currently sent with them. Shutdown returns the number
+// of connections closed forcefully, together with the error of ctx.
+func (p *ConnectionPool) Shutdown(ctx context.Context) (forceClosed int, err error) {
+	p.shutdownOnce.Do(func() {
+		close(p.closed)
+		p.breaker.stop()
+	})
 	p.mu.Lock()
+	for _, r := range p.queued {
+		r.errChan <- ErrPoolClosed
+	}
+	p.queued = nil
 	for _, c := range p.idles {
-		c.socket.Close()
-		p.numOpen--
+		p.retire(c, closeShutdown)
 	}
+	p.idles = nil
 	p.mu.Unlock()
+
+	for {
+		p.mu.Lock()
+		numOpen := p.numOpen
+		p.mu.Unlock()
+		if numOpen == 0 {
+			return 0, nil
+		}
+		select {
+		case <-p.released:
+		case <-ctx.Done():
+			return p.forceClose(), ctx.Err()
+		}
+	}
+}
+
+// forceClose closes all open connections, including the ones in use.
+func (p *ConnectionPool) forceClose() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for c := range p.conns {
+		p.logger.Debug("force-close", "id", c.id)
+		p.retire(c, closeShutdown)
+		n++
+	}
+	return n
+}
+
+// retire closes the connection for the given reason. p.mu must be held. A connection, which was closed already, is
+// not closed again.
+func (p *ConnectionPool) retire(c *Connection, reason closeReason) {
+	if _, ok := p.conns[c]; !ok {
+		return
+	}
+	delete(p.conns, c)
+	c.socket.Close()
+	p.stats.closed[reason].Add(1)
+	p.release()
+}
+
+// release frees the slot of a connection, which was closed or could not be opened. As long as the pool is not shut
+// down, a new connection is opened for a queued request, if any. p.mu must be held.
+func (p *ConnectionPool) release() {
+	p.numOpen--
+	if p.isClosed() {
+		select {
+		case p.released <- struct{}{}:
+		default:
+		}
+		return
+	}
+	p.openForQueued()
+}
+
+func (p *ConnectionPool) isClosed() bool {
+	select {
+	case <-p.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// popIdle takes the most recently returned idle connection, which can still be used, from the pool. Idle connections,
+// which expired or were closed by the server, are closed. p.mu must be held.
+func (p *ConnectionPool) popIdle() *Connection {
+	now := time.Now()
+	for len(p.idles) > 0 {
+		c := p.idles[len(p.idles)-1]
+		p.idles = p.idles[:len(p.idles)-1]
+		if reason, ok := p.idleRetireReason(c, now); ok {
+			p.logger.Debug("retire-idle", "id", c.id)
+			p.retire(c, reason)
+			continue
+		}
+		return c
+	}
+	return nil
+}
+
+// idleRetireReason reports whether the idle connection needs to be closed, and why.
+func (p *ConnectionPool) idleRetireReason(c *Connection, now time.Time) (closeReason, bool) {
+	switch {
+	case p.expired(c, now):
+		return closeMaxLifetime, true
+	case c.idleExpired(p.maxIdleTime, now):
+		return closeMaxIdleTime, true
+	case !c.socket.healthy():
+		return closeUnhealthy, true
+	}
+	return 0, false
+}
+
+// expired reports whether the connection exceeded the MaxConnectionLifetime.
+func (p *ConnectionPool) expired(c *Connection, now time.Time) bool {
+	return p.maxLifetime > 0 && now.Sub(c.createdAt) >= p.maxLifetime
+}
+
+// maintenanceInterval returns the interval in which maintain needs to run, or 0, if no maintenance is needed.
+func (p *ConnectionPool) maintenanceInterval() time.Duration {
+	var i time.Duration
+	for _, d := range []time.Duration{p.maxLifetime, p.maxIdleTime, p.healthCheck} {
+		if d > 0 && (i == 0 || d < i) {
+			i = d
+		}
+	}
+	if i == 0 && p.minIdleCount > 0 {
+		i = defaultMaintenanceInterval
+	}
+	if i == 0 {
+		return 0
+	}
+	return max(i, minMaintenanceInterval)
+}
+
+// maintain closes expired and unhealthy idle connections and opens new ones, to keep MinIdleConnections idle
+// connections in the pool. It runs until the pool is shut down.
+func (p *ConnectionPool) maintain(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	var lastCheck time.Time
+	for {
+		p.retireIdles()
+		if p.healthCheck > 0 && time.Since(lastCheck) >= p.healthCheck {
+			p.checkIdles()
+			lastCheck = time.Now()
+		}
+		p.fillIdles()
+		select {
+		case <-t.C:
+		case <-p.closed:
+			return
+		}
+	}
+}
+
+// retireIdles closes all idle connections, which expired.
+func (p *ConnectionPool) retireIdles() {
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	idles := p.idles[:0]
+	for _, c := range p.idles {
+		if reason, ok := p.idleRetireReason(c, now); ok {
+			p.logger.Debug("retire-idle", "id", c.id)
+			p.retire(c, reason)
+			continue
+		}
+		idles = append(idles, c)
+	}
+	clear(p.idles[len(idles):])
+	p.idles = idles
+}
+
+// checkIdles sends a cheap read command with each idle connection. A connection is taken out of the pool while it is
+// checked, and closed if the check failed.
+func (p *ConnectionPool) checkIdles() {
+	p.mu.Lock()
+	idles := slices.Clone(p.idles)
+	p.mu.Unlock()
+	for _, c := range idles {
+		p.mu.Lock()
+		i := slices.Index(p.idles, c)
+		if i == -1 {
+			// the connection was handed out or retired in the meantime
+			p.mu.Unlock()
+			continue
+		}
+		p.idles = slices.Delete(p.idles, i, i+1)
+		p.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+		err := c.ping(ctx)
+		cancel()
+		if err != nil {
+			p.logger.Debug("health-check-failed", "id", c.id, "error", err)
+			p.mu.Lock()
+			p.retire(c, closeUnhealthy)
+			p.mu.Unlock()
+			continue
+		}
+		// the health check does not count as usage, the connection is still idle since it was returned
+		p.put(c, nil, c.idleSince)
+	}
+}
+
+// fillIdles opens new connections until the pool has MinIdleConnections idle connections, or MaxOpenConnections
+// is reached.
+func (p *ConnectionPool) fillIdles() {
+	for !p.isClosed() {
+		p.mu.Lock()
+		if len(p.idles) >= p.minIdleCount || p.numOpen >= p.maxOpenCount {
+			p.mu.Unlock()
+			return
+		}
+		p.numOpen++
+		p.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+		c, err := p.new(ctx)
+		cancel()
+		p.mu.Lock()
+		if err != nil {
+			p.logger.Debug("pre-warm-failed", "error", err)
+			p.release()
+			p.mu.Unlock()
+			return
+		}
+		p.conns[c] = struct{}{}
+		p.mu.Unlock()
+		p.put(c, nil, time.Now())
+	}
 }
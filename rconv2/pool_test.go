@@ -0,0 +1,473 @@
+package rconv2_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/floriansw/go-hll-rcon/rconv2"
+	"github.com/floriansw/go-hll-rcon/rconv2/rconv2test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConnectionPool", func() {
+	var s *rconv2test.Server
+	var ctx context.Context
+
+	BeforeEach(func() {
+		var err error
+		s, err = rconv2test.NewServer(password)
+		Expect(err).ToNot(HaveOccurred())
+		s.Respond("GetServerInformation", map[string]any{})
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		Expect(s.Close()).To(Succeed())
+	})
+
+	It("does not hand out idle connections the server closed", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{})
+		defer p.Shutdown(context.Background())
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		p.Return(c, nil)
+
+		s.DisconnectAll()
+		Eventually(s.OpenConnections).Should(Equal(0))
+		// give the connection a moment to notice the closed connection
+		time.Sleep(50 * time.Millisecond)
+		n, err := p.Get(ctx)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).ToNot(BeIdenticalTo(c))
+		p.Return(n, nil)
+	})
+
+	It("closes connections exceeding their maximum lifetime", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{MaxConnectionLifetime: 100 * time.Millisecond})
+		defer p.Shutdown(context.Background())
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		p.Return(c, nil)
+		Expect(s.OpenConnections()).To(Equal(1))
+
+		Eventually(s.OpenConnections).Should(Equal(0))
+		n, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).ToNot(BeIdenticalTo(c))
+		p.Return(n, nil)
+	})
+
+	It("closes connections idle for longer than the maximum idle time", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{MaxIdleTime: 100 * time.Millisecond})
+		defer p.Shutdown(context.Background())
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		p.Return(c, nil)
+
+		Eventually(s.OpenConnections).Should(Equal(0))
+	})
+
+	It("checks the health of idle connections", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{HealthCheckInterval: 50 * time.Millisecond})
+		defer p.Shutdown(context.Background())
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		p.Return(c, nil)
+
+		Eventually(func() int { return len(s.RequestsFor("GetServerInformation")) }).Should(BeNumerically(">=", 2))
+		Expect(s.OpenConnections()).To(Equal(1))
+
+		s.Handle("GetServerInformation", func(r rconv2test.Request) rconv2test.Response {
+			return rconv2test.Response{StatusCode: 500}
+		})
+		Eventually(s.OpenConnections).Should(Equal(0))
+	})
+
+	It("pre-warms the minimum number of idle connections", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{MinIdleConnections: ptr(2)})
+		defer p.Shutdown(context.Background())
+
+		Eventually(s.OpenConnections).Should(Equal(2))
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		defer p.Return(c, nil)
+		Expect(s.Accepted()).To(Equal(2))
+	})
+
+	It("hands out returned connections to queued requests in order", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{MaxOpenConnections: ptr(1), MaxIdleConnections: ptr(1)})
+		defer p.Shutdown(context.Background())
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		var order []int
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for i := range 3 {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				n, err := p.Get(ctx)
+				Expect(err).ToNot(HaveOccurred())
+				mu.Lock()
+				order = append(order, i)
+				mu.Unlock()
+				p.Return(n, nil)
+			}()
+			Eventually(func() int { return p.Stats().Waiting }).Should(Equal(i + 1))
+		}
+		p.Return(c, nil)
+		wg.Wait()
+
+		Expect(order).To(Equal([]int{0, 1, 2}))
+	})
+
+	It("removes cancelled requests from the queue", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{MaxOpenConnections: ptr(1), MaxIdleConnections: ptr(1)})
+		defer p.Shutdown(context.Background())
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		cctx, cancel := context.WithCancel(ctx)
+		go func() {
+			defer GinkgoRecover()
+			Eventually(func() int { return p.Stats().Waiting }).Should(Equal(1))
+			cancel()
+		}()
+		_, err = p.Get(cctx)
+		Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		Expect(p.Stats().Waiting).To(Equal(0))
+
+		p.Return(c, nil)
+		Expect(p.Stats().Idle).To(Equal(1))
+		tctx, tcancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer tcancel()
+		n, err := p.Get(tctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).To(BeIdenticalTo(c))
+		p.Return(n, nil)
+	})
+
+	It("times out queued requests at the deadline of the context", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{MaxOpenConnections: ptr(1), MaxIdleConnections: ptr(1)})
+		defer p.Shutdown(context.Background())
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		defer p.Return(c, nil)
+
+		tctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err = p.Get(tctx)
+
+		var terr interface{ Timeout() bool }
+		Expect(errors.As(err, &terr)).To(BeTrue())
+		Expect(terr.Timeout()).To(BeTrue())
+		Expect(p.Stats().Waiting).To(Equal(0))
+	})
+
+	It("opens a new connection for a queued request when a broken connection is returned", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{MaxOpenConnections: ptr(1), MaxIdleConnections: ptr(1)})
+		defer p.Shutdown(context.Background())
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		go func() {
+			defer GinkgoRecover()
+			Eventually(func() int { return p.Stats().Waiting }).Should(Equal(1))
+			p.Return(c, rconv2.ErrProtocolDesync)
+		}()
+		n, err := p.Get(ctx)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).ToNot(BeIdenticalTo(c))
+		p.Return(n, nil)
+		Expect(p.Stats().OpenConnections).To(Equal(1))
+	})
+
+	It("does not lose connections under concurrent use", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{MaxOpenConnections: ptr(5), MaxIdleConnections: ptr(5)})
+		defer p.Shutdown(context.Background())
+
+		var succeeded, failed atomic.Int64
+		var wg sync.WaitGroup
+		for i := range 2000 {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				// some requests give up while waiting, some get cancelled right away
+				cctx, cancel := context.WithCancel(ctx)
+				if i%3 != 0 {
+					cctx, cancel = context.WithTimeout(ctx, time.Duration(i%50)*time.Millisecond)
+				}
+				defer cancel()
+				err := p.WithConnection(cctx, func(c *rconv2.Connection) error {
+					_, err := c.ServerConfig(ctx)
+					Expect(err).ToNot(HaveOccurred())
+					succeeded.Add(1)
+					return nil
+				})
+				if err != nil {
+					failed.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		Expect(succeeded.Load()).To(BeNumerically(">", 0))
+		Expect(succeeded.Load() + failed.Load()).To(Equal(int64(2000)))
+		st := p.Stats()
+		Expect(st.Waiting).To(Equal(0))
+		Expect(st.InUse).To(Equal(0))
+		Expect(st.OpenConnections).To(BeNumerically("<=", 5))
+		Expect(s.OpenConnections()).To(BeNumerically("<=", 5))
+
+		tctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		var conns []*rconv2.Connection
+		for range 5 {
+			c, err := p.Get(tctx)
+			Expect(err).ToNot(HaveOccurred())
+			conns = append(conns, c)
+		}
+		for _, c := range conns {
+			p.Return(c, nil)
+		}
+	})
+
+	It("returns the error of the function and retires broken connections", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{})
+		defer p.Shutdown(context.Background())
+
+		err := p.WithConnection(ctx, func(c *rconv2.Connection) error {
+			return rconv2.ErrProtocolDesync
+		})
+
+		Expect(errors.Is(err, rconv2.ErrProtocolDesync)).To(BeTrue())
+		Expect(p.Stats().ClosedBroken).To(Equal(int64(1)))
+		Expect(p.Stats().Idle).To(Equal(0))
+	})
+
+	It("retries reads once with another connection when the connection broke", func() {
+		var calls atomic.Int32
+		s.Handle("GetServerInformation", func(r rconv2test.Request) rconv2test.Response {
+			if calls.Add(1) == 1 {
+				return rconv2test.Response{Disconnect: true}
+			}
+			return rconv2test.Response{Content: map[string]any{}}
+		})
+		p := newPool(s, rconv2.ConnectionPoolOptions{RetryBrokenConnections: true})
+		defer p.Shutdown(context.Background())
+
+		var conns []*rconv2.Connection
+		var errs []error
+		err := p.WithConnection(ctx, func(c *rconv2.Connection) error {
+			conns = append(conns, c)
+			_, err := c.ServerConfig(ctx)
+			errs = append(errs, err)
+			return err
+		})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(conns).To(HaveLen(2))
+		Expect(conns[1]).ToNot(BeIdenticalTo(conns[0]))
+		Expect(errors.Is(errs[0], rconv2.ErrConnectionLost)).To(BeTrue())
+		Expect(rconv2.IsBrokenHllConnection(errs[0])).To(BeTrue())
+		Expect(p.Stats().ClosedBroken).To(Equal(int64(1)))
+	})
+
+	It("does not retry functions which sent mutating commands", func() {
+		s.Respond("KickPlayer", nil)
+		p := newPool(s, rconv2.ConnectionPoolOptions{RetryBrokenConnections: true})
+		defer p.Shutdown(context.Background())
+
+		calls := 0
+		err := p.WithConnection(ctx, func(c *rconv2.Connection) error {
+			calls++
+			if err := c.KickPlayer(ctx, "1", "reason"); err != nil {
+				return err
+			}
+			return rconv2.ErrProtocolDesync
+		})
+
+		Expect(errors.Is(err, rconv2.ErrProtocolDesync)).To(BeTrue())
+		Expect(calls).To(Equal(1))
+	})
+
+	It("does not retry functions by default", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{})
+		defer p.Shutdown(context.Background())
+
+		calls := 0
+		err := p.WithConnection(ctx, func(c *rconv2.Connection) error {
+			calls++
+			return rconv2.ErrProtocolDesync
+		})
+
+		Expect(err).To(HaveOccurred())
+		Expect(calls).To(Equal(1))
+	})
+
+	It("rejects requests for connections after the shutdown", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{})
+		n, err := p.Shutdown(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).To(Equal(0))
+
+		_, err = p.Get(ctx)
+		Expect(errors.Is(err, rconv2.ErrPoolClosed)).To(BeTrue())
+	})
+
+	It("fails queued requests when shut down", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{MaxOpenConnections: ptr(1), MaxIdleConnections: ptr(1)})
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		errs := make(chan error, 1)
+		go func() {
+			_, err := p.Get(ctx)
+			errs <- err
+		}()
+		Eventually(func() int { return p.Stats().Waiting }).Should(Equal(1))
+		go func() {
+			defer GinkgoRecover()
+			_, err := p.Shutdown(ctx)
+			Expect(err).ToNot(HaveOccurred())
+		}()
+
+		Eventually(errs).Should(Receive(MatchError(rconv2.ErrPoolClosed)))
+		p.Return(c, nil)
+	})
+
+	It("waits for connections in use to be returned when shut down", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{})
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		idle, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		p.Return(idle, nil)
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			p.Return(c, nil)
+		}()
+		tctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		n, err := p.Shutdown(tctx)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).To(Equal(0))
+		Expect(p.Stats().OpenConnections).To(Equal(0))
+		Eventually(s.OpenConnections).Should(Equal(0))
+	})
+
+	It("force-closes connections still in use when the context of the shutdown expires", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{})
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		tctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		n, err := p.Shutdown(tctx)
+
+		Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		Expect(n).To(Equal(1))
+		Eventually(s.OpenConnections).Should(Equal(0))
+		_, err = c.ServerConfig(ctx)
+		Expect(err).To(HaveOccurred())
+		p.Return(c, err)
+		Expect(p.Stats().OpenConnections).To(Equal(0))
+	})
+
+	It("does not block other requests while opening a connection", func() {
+		dialing := make(chan struct{}, 1)
+		unblock := make(chan struct{})
+		var block atomic.Bool
+		p := newPool(s, rconv2.ConnectionPoolOptions{
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				if block.Load() {
+					dialing <- struct{}{}
+					<-unblock
+				}
+				var d net.Dialer
+				return d.DialContext(ctx, network, address)
+			},
+		})
+		defer p.Shutdown(context.Background())
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		block.Store(true)
+		opened := make(chan *rconv2.Connection, 1)
+		go func() {
+			defer GinkgoRecover()
+			n, err := p.Get(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			opened <- n
+		}()
+		Eventually(dialing).Should(Receive())
+
+		Expect(p.Stats().OpenConnections).To(Equal(2))
+		p.Return(c, nil)
+		idle, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(idle).To(BeIdenticalTo(c))
+		p.Return(idle, nil)
+
+		close(unblock)
+		var n *rconv2.Connection
+		Eventually(opened).Should(Receive(&n))
+		p.Return(n, nil)
+	})
+
+	It("closes connections opened while the pool was shut down", func() {
+		dialing := make(chan struct{}, 1)
+		unblock := make(chan struct{})
+		p := newPool(s, rconv2.ConnectionPoolOptions{
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				dialing <- struct{}{}
+				<-unblock
+				var d net.Dialer
+				return d.DialContext(ctx, network, address)
+			},
+		})
+		errs := make(chan error, 1)
+		go func() {
+			_, err := p.Get(ctx)
+			errs <- err
+		}()
+		Eventually(dialing).Should(Receive())
+
+		shutdown := make(chan int, 1)
+		go func() {
+			defer GinkgoRecover()
+			n, err := p.Shutdown(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			shutdown <- n
+		}()
+		Consistently(shutdown, 50*time.Millisecond).ShouldNot(Receive())
+		close(unblock)
+
+		Eventually(errs).Should(Receive(MatchError(rconv2.ErrPoolClosed)))
+		Eventually(shutdown).Should(Receive(Equal(0)))
+		Eventually(s.OpenConnections).Should(Equal(0))
+	})
+
+	It("rejects more minimum than maximum idle connections", func() {
+		_, err := rconv2.NewConnectionPool(rconv2.ConnectionPoolOptions{
+			Hostname:           s.Host(),
+			Port:               s.Port(),
+			MaxIdleConnections: ptr(1),
+			MinIdleConnections: ptr(2),
+		})
+
+		Expect(err).To(HaveOccurred())
+	})
+})
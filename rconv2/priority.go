@@ -0,0 +1,67 @@
+package rconv2
+
+import "slices"
+
+// Priority is the priority of a request for a Connection of a ConnectionPool, see ConnectionPool.GetWithPriority and
+// ConnectionPool.WithConnectionPriority.
+type Priority int
+
+const (
+	// PriorityNormal is the priority of requests without an explicit priority, e.g. polling information from the
+	// server.
+	PriorityNormal Priority = iota
+	// PriorityHigh requests are served before all requests with PriorityNormal, e.g. moderation actions of admins.
+	// They can use the connections reserved with ConnectionPoolOptions.ReservedConnections.
+	PriorityHigh
+)
+
+// allowed reports whether a request with the Priority can be given another Connection without using the reserved
+// connections. p.mu must be held.
+func (p *ConnectionPool) allowed(prio Priority) bool {
+	return prio == PriorityHigh || p.numNormal < p.maxOpenCount-p.reservedCount
+}
+
+// acquire hands out the Connection to a request with the Priority. p.mu must be held.
+func (p *ConnectionPool) acquire(c *Connection, prio Priority) *Connection {
+	c.inUse = true
+	p.numInUse++
+	if prio == PriorityNormal {
+		c.normal = true
+		p.numNormal++
+	}
+	return c
+}
+
+// enqueue queues the request behind all requests of the same or a higher Priority. p.mu must be held.
+func (p *ConnectionPool) enqueue(r *request) {
+	i := len(p.queued)
+	if r.priority == PriorityHigh {
+		i = slices.IndexFunc(p.queued, func(q *request) bool {
+			return q.priority != PriorityHigh
+		})
+		if i == -1 {
+			i = len(p.queued)
+		}
+	}
+	p.queued = slices.Insert(p.queued, i, r)
+}
+
+// nextQueued returns the index of the queued request to serve next, or -1, if no queued request can be served. Requests
+// with PriorityNormal are not served, if all remaining connections are reserved. p.mu must be held.
+func (p *ConnectionPool) nextQueued() int {
+	return slices.IndexFunc(p.queued, func(r *request) bool {
+		return p.allowed(r.priority)
+	})
+}
+
+// dequeueNext removes the queued request to serve next from the queue and returns it, or nil, if no queued request can
+// be served. p.mu must be held.
+func (p *ConnectionPool) dequeueNext() *request {
+	i := p.nextQueued()
+	if i == -1 {
+		return nil
+	}
+	r := p.queued[i]
+	p.queued = slices.Delete(p.queued, i, i+1)
+	return r
+}
@@ -0,0 +1,104 @@
+package rconv2_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/floriansw/go-hll-rcon/rconv2"
+	"github.com/floriansw/go-hll-rcon/rconv2/rconv2test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Priority", func() {
+	var s *rconv2test.Server
+	var ctx context.Context
+
+	BeforeEach(func() {
+		var err error
+		s, err = rconv2test.NewServer(password)
+		Expect(err).ToNot(HaveOccurred())
+		s.Respond("GetServerInformation", map[string]any{})
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		Expect(s.Close()).To(Succeed())
+	})
+
+	get := func(p *rconv2.ConnectionPool, prio rconv2.Priority, name string, got chan<- string) {
+		defer GinkgoRecover()
+		c, err := p.GetWithPriority(ctx, prio)
+		Expect(err).ToNot(HaveOccurred())
+		got <- name
+		time.Sleep(10 * time.Millisecond)
+		p.Return(c, nil)
+	}
+
+	It("serves queued requests with high priority first", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{MaxOpenConnections: ptr(1), MaxIdleConnections: ptr(1)})
+		defer p.Shutdown(ctx)
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		got := make(chan string, 3)
+		go get(p, rconv2.PriorityNormal, "normal", got)
+		Eventually(func() int { return p.Stats().Waiting }).Should(Equal(1))
+		go get(p, rconv2.PriorityHigh, "first high", got)
+		Eventually(func() int { return p.Stats().Waiting }).Should(Equal(2))
+		go get(p, rconv2.PriorityHigh, "second high", got)
+		Eventually(func() int { return p.Stats().Waiting }).Should(Equal(3))
+		p.Return(c, nil)
+
+		Eventually(got).Should(Receive(Equal("first high")))
+		Eventually(got).Should(Receive(Equal("second high")))
+		Eventually(got).Should(Receive(Equal("normal")))
+	})
+
+	It("keeps reserved connections for requests with high priority", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{
+			MaxOpenConnections:  ptr(2),
+			MaxIdleConnections:  ptr(2),
+			ReservedConnections: ptr(1),
+		})
+		defer p.Shutdown(ctx)
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		tctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err = p.Get(tctx)
+		Expect(err).To(HaveOccurred())
+		Expect(p.WithConnectionPriority(ctx, rconv2.PriorityHigh, func(c *rconv2.Connection) error {
+			_, err := c.ServerConfig(ctx)
+			return err
+		})).To(Succeed())
+
+		h, err := p.GetWithPriority(ctx, rconv2.PriorityHigh)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p.Stats().OpenConnections).To(Equal(2))
+
+		got := make(chan string, 1)
+		go get(p, rconv2.PriorityNormal, "normal", got)
+		Eventually(func() int { return p.Stats().Waiting }).Should(Equal(1))
+		// the reserved connection is kept idle for requests with high priority
+		p.Return(h, nil)
+		Consistently(got, 50*time.Millisecond).ShouldNot(Receive())
+		Expect(p.Stats().Idle).To(Equal(1))
+
+		p.Return(c, nil)
+		Eventually(got).Should(Receive(Equal("normal")))
+	})
+
+	It("rejects reserving all connections", func() {
+		_, err := rconv2.NewConnectionPool(rconv2.ConnectionPoolOptions{
+			Hostname:            s.Host(),
+			Port:                s.Port(),
+			MaxOpenConnections:  ptr(2),
+			MaxIdleConnections:  ptr(2),
+			ReservedConnections: ptr(2),
+		})
+
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -1,83 +1,151 @@
 package rconv2
 
 import (
+	"bufio"
 	"context"
 	"encoding/base64"
-	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net"
 	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 )
 
 const (
 	magicNumber = uint32(0xDE450508)
+	// headerSize is the size of the header preceding each message: magic number, request ID and content length.
+	headerSize = 12
 )
 
 var (
-	ErrWriteSentUnequal    = errors.New("write wrote less or more bytes than command is long")
-	ErrReadLengthUnequal   = errors.New("server wrote less bytes than advertised")
+	ErrWriteSentUnequal  = errors.New("write wrote less or more bytes than command is long")
+	ErrReadLengthUnequal = errors.New("server wrote less bytes than advertised")
+	ErrSocketClosed      = errors.New("socket is closed")
+	// ErrProtocolDesync is returned when the server sent data that does not follow the protocol, e.g. a message without
+	// the magic number, a message exceeding the maximum frame size or a response to a request that was never sent.
+	// The position of the next message in the stream is unknown afterward, hence the connection is given up.
+	ErrProtocolDesync = errors.New("protocol desynchronised")
+	// ErrConnectionLost is returned for commands, which could not be completed because the connection to the server
+	// was lost, e.g. because the server closed it or restarted. It wraps the error of the underlying connection.
+	ErrConnectionLost      = errors.New("connection lost")
 	ReconnectTriesExceeded = errors.New("there are no reconnects left")
+
+	defaultRequestTimeout = 20 * time.Second
+	defaultMaxFrameSize   = uint32(16 * 1024 * 1024)
 )
 
+// socket is a connection to the RCon server which re-establishes itself when the server closed the underlying
+// TCP connection. Requests can be sent from multiple goroutines at the same time, they are multiplexed onto the same
+// TCP connection and responses are matched to their requests by the request ID in the header of each message.
 type socket struct {
-	con            net.Conn
-	pw             string
-	host           string
-	port           int
+	opts socketOptions
+
+	// dialing is held while a new connection is established, so that only one goroutine reconnects at a time.
+	dialing        chan struct{}
+	mu             sync.Mutex
+	mc             *muxConn
 	reconnectCount int
+	closed         bool
+	// authGeneration is incremented whenever the socket obtained a new auth token, either by reconnecting or by
+	// re-authenticating the current connection.
+	authGeneration uint64
+}
+
+type socketOptions struct {
+	host      string
+	port      int
+	pw        string
+	dial      DialFunc
+	reconnect ReconnectPolicy
+	// onReauthenticate is called after the socket tried to re-authenticate with the server. err is nil, if the
+	// re-authentication succeeded.
+	onReauthenticate func(err error)
+	// onConnect is called whenever a TCP connection to the server was established.
+	onConnect func()
+	// onLogin is called after each login attempt, including re-authentications. err is nil, if the login succeeded.
+	onLogin      func(err error)
+	recorder     *Recorder
+	maxFrameSize uint32
+}
 
-	xorKey    []byte
-	authToken string
+// muxConn is a single, authenticated TCP connection to the RCon server. Any number of requests can be in flight on
+// a muxConn at the same time. A dedicated goroutine reads all responses from the connection and hands them over to
+// the request waiting for the respective request ID.
+type muxConn struct {
+	con net.Conn
+	// reader buffers reads from con, so that the header and the content of a message are usually read at once.
+	reader *bufio.Reader
+	// writing is held while a message is written to con, messages from different goroutines must not interleave.
+	writing chan struct{}
+	// fw encodes the messages written to con, it is only used while holding writing.
+	fw *frameWriter
+	// auth is held for reading by each request in flight and for writing while the connection re-authenticates, so
+	// that no request is sent with an outdated auth token or XOR key.
+	auth sync.RWMutex
+	// recorder, if not nil, receives every request sent over the connection together with its response.
+	recorder     *Recorder
+	maxFrameSize uint32
 
-	lastContext *context.Context
-	// TODO Completely the wrong place for that, probably. But, the library does not support sending multiple
-	// commands over the same socket yet, anyway. Hence it doesn't matter, it just needs to go somewhere for now.
+	mu            sync.Mutex
+	xorKey        []byte
+	authToken     string
 	lastRequestId uint32
+	pending       map[uint32]chan frame
+	// err is the reason the connection was given up. Once set, no new requests are accepted.
+	err error
+}
+
+// frame is the response to a request. The content of the response is stored in a pooled buffer, which needs to be
+// returned with putBuffer once the content was processed.
+type frame struct {
+	buf *[]byte
+	err error
+}
+
+// pendingRequest is a request that was written to the connection and waits for the server to respond.
+type pendingRequest struct {
+	mc *muxConn
+	id uint32
+	ch chan frame
 }
 
 type Request[T, U any] struct {
 	Body T
 }
 
-func (r *Request[T, U]) do(s *socket) (result Response[U], err error) {
-	b := marshal(r.asRawRequest(s.authToken))
-	err = s.write(b)
-	if err != nil {
-		return result, err
-	}
-	res, err := s.read()
-	if err != nil {
-		return result, err
+func (r *Request[T, U]) asRawRequest() rawRequest {
+	return newRawRequest(commandName(r.Body), r.Body)
+}
+
+// commandName returns the name of the command the request body belongs to. This is either the name returned by
+// the Command interface, or the name of the type of body.
+func commandName(body any) string {
+	if c, ok := body.(Command); ok {
+		return c.CommandName()
 	}
-	err = json.Unmarshal(res, &result)
-	return result, err
+	return reflect.TypeOf(body).Name()
 }
 
-func (r *Request[T, U]) asRawRequest(authToken string) rawRequest {
-	body := r.Body
+func newRawRequest(cmd string, body any) rawRequest {
 	var d []byte
-	t := reflect.ValueOf(r.Body)
-	if t.Kind() == reflect.String {
+	t := reflect.ValueOf(body)
+	if body == nil {
+		d = []byte{}
+	} else if t.Kind() == reflect.String {
 		d = []byte(t.String())
 	} else {
 		d, _ = json.Marshal(body)
 	}
-	var cmd string
-	if c, ok := any(body).(Command); ok {
-		cmd = c.CommandName()
-	} else {
-		cmd = reflect.TypeOf(body).Name()
-	}
 	return rawRequest{
-		Command:   cmd,
-		Body:      string(d),
-		AuthToken: authToken,
-		Version:   2,
+		Command: cmd,
+		Body:    string(d),
+		Version: 2,
 	}
 }
 
@@ -89,11 +157,34 @@ type Response[T any] struct {
 	Content       string `json:"contentBody"`
 }
 
-func (r *Response[T]) Body() (res T) {
-	if _, ok := any(res).(string); ok {
-		return any(r.Content).(T)
+// Body decodes the contentBody of the response into a value of type T. A string T receives the contentBody as is, an
+// interface{} T is not decoded at all. If the contentBody can not be decoded, a *DecodeError is returned.
+func (r *Response[T]) Body() (T, error) {
+	return r.decode(false)
+}
+
+// decode decodes the contentBody of the response like Body. If strict is true, fields in the contentBody, which do not
+// exist in T, are reported as DecodeError as well.
+func (r *Response[T]) decode(strict bool) (res T, err error) {
+	switch v := any(&res).(type) {
+	case *string:
+		*v = r.Content
+		return
+	case *any:
+		// commands without a response body
+		return
+	}
+	d := json.NewDecoder(strings.NewReader(r.Content))
+	if strict {
+		d.DisallowUnknownFields()
+	}
+	if err = d.Decode(&res); err == nil && d.More() {
+		err = errors.New("unexpected data after the JSON value")
+	}
+	if err != nil {
+		var zero T
+		return zero, &DecodeError{Command: r.Command, Content: r.Content, Err: err}
 	}
-	_ = json.Unmarshal([]byte(r.Content), &res)
 	return
 }
 
@@ -104,60 +195,305 @@ type rawRequest struct {
 	Version   int         `json:"Version"`
 }
 
-func (r *socket) SetContext(ctx context.Context) error {
-	r.lastContext = &ctx
-	if r.con == nil {
+// deadline returns the point in time until which an operation with the given context.Context is allowed to take.
+// Without a deadline in the context, the default request timeout applies.
+func deadline(ctx context.Context) time.Time {
+	if d, ok := ctx.Deadline(); ok {
+		return d
+	}
+	return time.Now().Add(defaultRequestTimeout)
+}
+
+// DialFunc opens a connection to the address (in the host:port notation) on the named network. The signature matches
+// net.Dialer.DialContext.
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+func makeConnectionV2(ctx context.Context, dial DialFunc, h string, p int, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if dial == nil {
+		d := net.Dialer{}
+		dial = d.DialContext
+	}
+	return dial(ctx, "tcp", net.JoinHostPort(h, strconv.Itoa(p)))
+}
+
+func newSocket(ctx context.Context, opts socketOptions) (*socket, error) {
+	r := &socket{
+		opts:           opts,
+		dialing:        make(chan struct{}, 1),
+		reconnectCount: 0,
+	}
+	return r, r.reconnect(ctx, nil, nil)
+}
+
+func (r *socket) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	if r.mc == nil {
 		return nil
 	}
-	if deadline, ok := ctx.Deadline(); ok {
-		return r.con.SetDeadline(deadline)
-	} else {
-		return r.con.SetDeadline(time.Now().Add(20 * time.Second))
+	return r.mc.close(ErrSocketClosed)
+}
+
+// healthy reports whether the socket is connected to the server. A socket is not healthy anymore once it was closed,
+// or once the server closed the connection, even though the socket would reconnect with the next request.
+func (r *socket) healthy() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return !r.closed && r.mc != nil && r.mc.failed() == nil
+}
+
+// roundTrip sends the request to the server and waits for the response to it. If the server closed the connection
+// in the meantime, the request is sent over a newly established connection.
+// The returned buffer needs to be returned with putBuffer once the response was processed.
+func (r *socket) roundTrip(ctx context.Context, req rawRequest) (*[]byte, error) {
+	for {
+		mc, err := r.conn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		started := time.Now()
+		mc.auth.RLock()
+		req.AuthToken = mc.token()
+		p, err := mc.send(ctx, req)
+		if errors.Is(err, syscall.EPIPE) {
+			mc.auth.RUnlock()
+			if err = r.reconnect(ctx, mc, err); err != nil {
+				return nil, err
+			}
+			continue
+		} else if err != nil {
+			mc.auth.RUnlock()
+			mc.recorder.record(req, nil, started, err)
+			return nil, err
+		}
+		r.resetReconnectCount()
+		res, err := p.wait(ctx)
+		mc.auth.RUnlock()
+		mc.recorder.record(req, contentOf(res), started, err)
+		return res, err
 	}
 }
 
-func (r *socket) Context() context.Context {
-	if r.lastContext != nil {
-		return *r.lastContext
+// exchange sends the request to the server and decodes the envelope of the response. The content of the response is
+// left as is.
+func (r *socket) exchange(ctx context.Context, req rawRequest) (res Response[string], err error) {
+	d, err := r.roundTrip(ctx, req)
+	if err != nil {
+		return res, err
 	}
-	return context.Background()
+	defer putBuffer(d)
+	err = json.Unmarshal(*d, &res)
+	return res, err
 }
 
-func makeConnectionV2(h string, p int) (net.Conn, error) {
-	return net.DialTimeout("tcp4", fmt.Sprintf("%s:%d", h, p), 5*time.Second)
+// generation returns the current auth generation of the socket. It is used to determine, if the socket obtained a
+// new auth token after a command was sent.
+func (r *socket) generation() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.authGeneration
 }
 
-func newSocket(ctx context.Context, h string, p int, pw string) (*socket, error) {
-	r := &socket{
-		pw:             pw,
-		host:           h,
-		port:           p,
-		reconnectCount: 0,
+// reauthenticate repeats the ServerConnect and Login handshake on the current connection, e.g. after the server
+// rejected the auth token because it restarted. If the socket already obtained a new auth token since generation gen
+// (because another goroutine re-authenticated or reconnected in the meantime), nothing is done.
+// Requests in flight are finished before the handshake starts, new requests wait until it is done.
+func (r *socket) reauthenticate(ctx context.Context, gen uint64) (err error) {
+	select {
+	case r.dialing <- struct{}{}:
+		defer func() { <-r.dialing }()
+	case <-ctx.Done():
+		return newCommandAborted(ctx)
+	}
+	r.mu.Lock()
+	mc := r.mc
+	if r.authGeneration != gen || mc == nil {
+		r.mu.Unlock()
+		return nil
 	}
-	_ = r.SetContext(ctx)
-	return r, r.reconnect(nil)
+	r.mu.Unlock()
+	if r.opts.onReauthenticate != nil {
+		defer func() { r.opts.onReauthenticate(err) }()
+	}
+
+	mc.auth.Lock()
+	defer mc.auth.Unlock()
+	err = mc.greatServer(ctx)
+	if err != nil {
+		_ = mc.close(err)
+		return handshakeError("great", err, nil)
+	}
+	err = r.login(ctx, mc)
+	if err != nil {
+		_ = mc.close(err)
+		return handshakeError("login", err, nil)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.authGeneration++
+	return nil
 }
 
-func (r *socket) Close() error {
-	return r.con.Close()
+// conn returns the currently established connection. A connection that was given up, e.g. because the server closed
+// it, is replaced by a new one.
+func (r *socket) conn(ctx context.Context) (*muxConn, error) {
+	r.mu.Lock()
+	mc := r.mc
+	r.mu.Unlock()
+	if mc != nil && mc.failed() == nil {
+		return mc, nil
+	}
+	if err := r.reconnect(ctx, mc, mc.failed()); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.mc, nil
 }
 
-func (r *socket) login() error {
-	req := rawRequest{
-		Command: "Login",
-		Version: 2,
-		Body:    r.pw,
+// reconnect replaces the connection old with a newly established and authenticated one. If another goroutine already
+// replaced old, the socket is left untouched.
+// Failed attempts are retried according to the ReconnectPolicy of the socket. The number of attempts accumulates
+// across calls until a command was sent successfully, which prevents endless reconnects to a server that accepts
+// connections but closes them right away.
+func (r *socket) reconnect(ctx context.Context, old *muxConn, orig error) error {
+	select {
+	case r.dialing <- struct{}{}:
+		defer func() { <-r.dialing }()
+	case <-ctx.Done():
+		return newCommandAborted(ctx)
+	}
+	if old != nil {
+		_ = old.close(orig)
+	}
+	var lastErr error
+	for {
+		r.mu.Lock()
+		if r.closed {
+			r.mu.Unlock()
+			return ErrSocketClosed
+		}
+		if r.mc != old {
+			r.mu.Unlock()
+			return nil
+		}
+		attempt := r.reconnectCount
+		if attempt >= r.opts.reconnect.MaxAttempts {
+			// the next caller starts with a fresh set of attempts, e.g. once the server is reachable again
+			r.reconnectCount = 0
+			r.mu.Unlock()
+			if lastErr == nil {
+				lastErr = orig
+			}
+			return fmt.Errorf("%w (%d attempts): %w", r.opts.reconnect.GiveUpError, attempt, lastErr)
+		}
+		r.reconnectCount++
+		r.mu.Unlock()
+
+		if err := sleep(ctx, r.opts.reconnect.backoff(attempt)); err != nil {
+			return abortedAfter(err, lastErr)
+		}
+		mc, err := r.dial(ctx, orig)
+		if errors.Is(err, ErrCommandAborted) {
+			return abortedAfter(err, lastErr)
+		} else if errors.Is(err, ErrInvalidCredentials) {
+			return err
+		} else if err != nil {
+			lastErr = err
+			continue
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.closed {
+			_ = mc.close(ErrSocketClosed)
+			return ErrSocketClosed
+		}
+		r.mc = mc
+		r.authGeneration++
+		return nil
+	}
+}
+
+// dial establishes a new connection to the server and authenticates it.
+func (r *socket) dial(ctx context.Context, orig error) (*muxConn, error) {
+	con, err := makeConnectionV2(ctx, r.opts.dial, r.opts.host, r.opts.port, r.opts.reconnect.DialTimeout)
+	if ctx.Err() != nil {
+		return nil, newCommandAborted(ctx)
+	} else if err != nil {
+		return nil, err
 	}
-	err := r.write(marshal(req))
+	if r.opts.onConnect != nil {
+		r.opts.onConnect()
+	}
+	mc := newMuxConn(con, r.opts)
+	err = mc.greatServer(ctx)
 	if err != nil {
-		return err
+		_ = mc.close(err)
+		return nil, handshakeError("great", err, orig)
 	}
-	res, err := r.read()
+	err = r.login(ctx, mc)
+	if err != nil {
+		_ = mc.close(err)
+		return nil, handshakeError("login", err, orig)
+	}
+	return mc, nil
+}
+
+// login logs in to the server with the password of the socket.
+func (r *socket) login(ctx context.Context, mc *muxConn) error {
+	err := mc.login(ctx, r.opts.pw)
+	if r.opts.onLogin != nil {
+		r.opts.onLogin(err)
+	}
+	return err
+}
+
+// handshakeError wraps the error of a failed handshake step together with the error that caused the reconnect, if any.
+func handshakeError(step string, err, orig error) error {
+	if orig == nil {
+		return fmt.Errorf("%s failed: %w", step, err)
+	}
+	return fmt.Errorf("%s failed: %w, original error: %w", step, err, orig)
+}
+
+func (r *socket) resetReconnectCount() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reconnectCount = 0
+}
+
+func newMuxConn(con net.Conn, opts socketOptions) *muxConn {
+	if opts.maxFrameSize == 0 {
+		opts.maxFrameSize = defaultMaxFrameSize
+	}
+	mc := &muxConn{
+		con:          con,
+		reader:       bufio.NewReaderSize(con, defaultBufferSize),
+		fw:           newFrameWriter(),
+		recorder:     opts.recorder,
+		maxFrameSize: opts.maxFrameSize,
+		writing:      make(chan struct{}, 1),
+		pending:      map[uint32]chan frame{},
+	}
+	go mc.readLoop()
+	return mc
+}
+
+func (m *muxConn) login(ctx context.Context, pw string) error {
+	res, err := m.roundTrip(ctx, rawRequest{
+		Command: "Login",
+		Version: 2,
+		Body:    pw,
+	})
 	if err != nil {
 		return err
 	}
+	defer putBuffer(res)
 	var data Response[string]
-	err = json.Unmarshal(res, &data)
+	err = json.Unmarshal(*res, &data)
 	if err != nil {
 		return err
 	}
@@ -166,140 +502,242 @@ func (r *socket) login() error {
 	} else if data.StatusCode != 200 {
 		return NewUnexpectedStatus(data.StatusCode, data.StatusMessage)
 	}
-	r.authToken = data.Content
+	m.mu.Lock()
+	m.authToken = data.Content
+	m.mu.Unlock()
 	return nil
 }
 
-func (r *socket) greatServer() error {
-	req := rawRequest{
+func (m *muxConn) greatServer(ctx context.Context) error {
+	res, err := m.roundTrip(ctx, rawRequest{
 		Command: "ServerConnect",
 		Version: 2,
 		Body:    nil,
-	}
-	err := r.write(marshal(req))
-	if err != nil {
-		return err
-	}
-	res, err := r.read()
+	})
 	if err != nil {
 		return err
 	}
+	defer putBuffer(res)
 	var data Response[[]byte]
-	err = json.Unmarshal(res, &data)
+	err = json.Unmarshal(*res, &data)
 	if err != nil {
 		return err
 	}
 	if data.StatusCode != 200 {
 		return NewUnexpectedStatus(data.StatusCode, data.StatusMessage)
 	}
-	r.xorKey, err = base64.StdEncoding.AppendDecode(r.xorKey, []byte(data.Content))
-	return err
-}
-
-func marshal(v rawRequest) []byte {
-	req, _ := json.Marshal(v)
-	return req
-}
-
-func (r *socket) retryableWrite(err error, cmd []byte) error {
-	err = r.reconnect(err)
+	key, err := base64.StdEncoding.DecodeString(data.Content)
 	if err != nil {
 		return err
 	}
-	return r.write(cmd)
+	m.mu.Lock()
+	m.xorKey = key
+	m.mu.Unlock()
+	return nil
 }
 
-func (r *socket) write(cmd []byte) error {
-	data := r.xor(cmd)
-	err := binary.Write(r.con, binary.LittleEndian, []uint32{magicNumber, r.lastRequestId, uint32(len(data))})
-	if errors.Is(err, syscall.EPIPE) {
-		return r.retryableWrite(err, cmd)
-	} else if err != nil {
-		return err
-	}
-	r.lastRequestId++
-	s, err := r.con.Write(data)
-	if errors.Is(err, syscall.EPIPE) {
-		return r.retryableWrite(err, cmd)
-	} else if err != nil {
-		return err
-	}
-	if s != len(cmd) {
-		return fmt.Errorf("%w Cmd: %s (%d), sent: %d", ErrWriteSentUnequal, cmd, len(cmd), s)
+func (m *muxConn) roundTrip(ctx context.Context, req rawRequest) (res *[]byte, err error) {
+	started := time.Now()
+	defer func() { m.recorder.record(req, contentOf(res), started, err) }()
+	p, err := m.send(ctx, req)
+	if err != nil {
+		return nil, err
 	}
+	return p.wait(ctx)
+}
 
-	r.resetReconnectCount()
-	return err
+// send assigns the next free request ID to req and writes it to the connection. The response can be obtained
+// from the returned pendingRequest.
+func (m *muxConn) send(ctx context.Context, req rawRequest) (*pendingRequest, error) {
+	m.mu.Lock()
+	if m.err != nil {
+		m.mu.Unlock()
+		return nil, m.err
+	}
+	m.lastRequestId++
+	p := &pendingRequest{
+		mc: m,
+		id: m.lastRequestId,
+		ch: make(chan frame, 1),
+	}
+	m.pending[p.id] = p.ch
+	m.mu.Unlock()
+
+	if err := m.write(ctx, p.id, req); err != nil {
+		m.forget(p.id)
+		return nil, err
+	}
+	return p, nil
 }
 
-func (r *socket) reconnect(orig error) error {
-	if r.reconnectCount > 3 {
-		return ReconnectTriesExceeded
+// write writes the message to the connection. A message that could not be written completely leaves the stream in an
+// undefined state for the server, hence the connection is given up on any error.
+func (m *muxConn) write(ctx context.Context, id uint32, req rawRequest) error {
+	select {
+	case m.writing <- struct{}{}:
+		defer func() { <-m.writing }()
+	case <-ctx.Done():
+		return newCommandAborted(ctx)
 	}
-	r.reconnectCount++
-	con, err := makeConnectionV2(r.host, r.port)
+
+	f, err := m.fw.encode(id, req, m.key())
 	if err != nil {
+		// nothing was written yet, the connection can still be used
 		return err
 	}
-	r.con = con
-	err = r.SetContext(r.Context())
+	err = m.con.SetWriteDeadline(deadline(ctx))
 	if err != nil {
+		_ = m.close(err)
 		return err
 	}
-	err = r.greatServer()
-	if err != nil {
-		return fmt.Errorf("great failed: %s, original error: %w", err.Error(), orig)
+	// interrupt a blocking write as soon as the context.Context is cancelled. The callback might still run after stop
+	// returned, it must not touch the write deadline once the next writer owns the connection.
+	var mu sync.Mutex
+	written := false
+	stop := context.AfterFunc(ctx, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if !written {
+			_ = m.con.SetWriteDeadline(time.Unix(1, 0))
+		}
+	})
+	defer func() {
+		stop()
+		mu.Lock()
+		written = true
+		mu.Unlock()
+	}()
+
+	err = m.writeFrame(f)
+	if err != nil && ctx.Err() != nil {
+		err = newCommandAborted(ctx)
+	} else if err != nil {
+		err = connectionLost(err)
 	}
-	err = r.login()
 	if err != nil {
-		return fmt.Errorf("login failed: %s, original error: %w", err.Error(), orig)
+		_ = m.close(err)
 	}
-	return nil
+	return err
 }
 
-func (r *socket) read() ([]byte, error) {
-	// each response has a fixed 12-byte header, the first 4 bytes is a magic number, followed by the response Id
-	// assigned by the server and the next 4 bytes is the content length of the response body
-	// byte format as used in python is: <III
-	var magic, responseId, contentLength uint32
-	err := binary.Read(r.con, binary.LittleEndian, &magic)
+// writeFrame writes the encoded frame with a single call to Write.
+func (m *muxConn) writeFrame(f []byte) error {
+	n, err := m.con.Write(f)
 	if err != nil {
-		return nil, fmt.Errorf("read magic number failed: %w", err)
+		return err
 	}
-	if magic != magicNumber {
-		return nil, fmt.Errorf("magic number does not match: expected %d to match %d", magic, magicNumber)
+	if n != len(f) {
+		return fmt.Errorf("%w: sent %d of %d bytes", ErrWriteSentUnequal, n, len(f))
 	}
-	err = binary.Read(r.con, binary.LittleEndian, &responseId)
-	if err != nil {
-		return nil, fmt.Errorf("read responseId failed: %w", err)
+	return nil
+}
+
+// wait blocks until the server responded to the request, the connection failed or the deadline of the
+// context.Context exceeded. The returned buffer needs to be returned with putBuffer once the response was processed.
+func (p *pendingRequest) wait(ctx context.Context) (*[]byte, error) {
+	defer p.mc.forget(p.id)
+	t := time.NewTimer(time.Until(deadline(ctx)))
+	defer t.Stop()
+
+	select {
+	case f := <-p.ch:
+		return f.buf, f.err
+	case <-ctx.Done():
+		return nil, newCommandAborted(ctx)
+	case <-t.C:
+		return nil, newResponseTimeout(p.id)
 	}
-	err = binary.Read(r.con, binary.LittleEndian, &contentLength)
-	if err != nil {
-		return nil, fmt.Errorf("read content length failed: %w", err)
+}
+
+func (m *muxConn) readLoop() {
+	for {
+		responseId, buf, err := m.read()
+		if err != nil {
+			_ = m.close(connectionLost(err))
+			return
+		}
+		m.mu.Lock()
+		if responseId > m.lastRequestId {
+			m.mu.Unlock()
+			putBuffer(buf)
+			_ = m.close(fmt.Errorf("%w: response to request %d which was not sent yet", ErrProtocolDesync, responseId))
+			return
+		}
+		ch, ok := m.pending[responseId]
+		delete(m.pending, responseId)
+		m.mu.Unlock()
+		// responses to requests nobody waits for anymore (e.g. because the context.Context exceeded) are dropped
+		if ok {
+			ch <- frame{buf: buf}
+		} else {
+			putBuffer(buf)
+		}
 	}
+}
 
-	answer := make([]byte, contentLength)
-	l, err := io.ReadFull(r.con, answer)
-	if len(answer) != l {
-		return nil, fmt.Errorf("%w responseId: %d, contentLength: %d, read: %d", ErrReadLengthUnequal, responseId, contentLength, l)
+// read reads the next message from the connection into a pooled buffer and decodes it.
+func (m *muxConn) read() (uint32, *[]byte, error) {
+	buf := getBuffer()
+	responseId, content, err := readFrame(m.reader, m.maxFrameSize, *buf)
+	if err != nil {
+		putBuffer(buf)
+		return 0, nil, err
 	}
+	xor(content, m.key())
+	*buf = content
+	return responseId, buf, nil
+}
+
+func (m *muxConn) key() []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.xorKey
+}
+
+func (m *muxConn) token() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.authToken
+}
 
-	return r.xor(answer), err
+func (m *muxConn) forget(id uint32) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.pending, id)
 }
 
-func (r *socket) xor(src []byte) []byte {
-	if r.xorKey == nil {
-		return src
+// failed returns the reason why the connection was given up, or nil, if the connection can still be used.
+func (m *muxConn) failed() error {
+	if m == nil {
+		return nil
 	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.err
+}
 
-	msg := make([]byte, len(src))
-	for i, b := range src {
-		msg[i] = b ^ r.xorKey[i%len(r.xorKey)]
+// connectionLost wraps err with ErrConnectionLost, if it indicates that the connection to the server was closed.
+func connectionLost(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) {
+		return fmt.Errorf("%w: %w", ErrConnectionLost, err)
 	}
-	return msg
+	return err
 }
 
-func (r *socket) resetReconnectCount() {
-	r.reconnectCount = 0
-	r.lastRequestId = 0
+// close gives up the connection and fails all requests that are still waiting for a response with err.
+func (m *muxConn) close(err error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return nil
+	}
+	if err == nil {
+		err = ErrSocketClosed
+	}
+	m.err = err
+	for id, ch := range m.pending {
+		ch <- frame{err: err}
+		delete(m.pending, id)
+	}
+	return m.con.Close()
 }
@@ -0,0 +1,67 @@
+package rconv2
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/floriansw/go-hll-rcon/rconv2/api"
+)
+
+var benchmarkKey = []byte("0123456789abcdef0123456789abcdef")
+
+func benchmarkPlayersResponse(b *testing.B) []byte {
+	var players api.GetPlayersResponse
+	for i := 0; i < 100; i++ {
+		players.Players = append(players.Players, api.GetPlayerResponse{
+			Id:   "7656119800000" + strconv.Itoa(1000+i),
+			Name: "Player " + strconv.Itoa(i),
+		})
+	}
+	content, err := json.Marshal(players)
+	if err != nil {
+		b.Fatal(err)
+	}
+	res, err := json.Marshal(Response[string]{StatusCode: 200, StatusMessage: "OK", Version: 2, Command: "GetServerInformation", Content: string(content)})
+	if err != nil {
+		b.Fatal(err)
+	}
+	xor(res, benchmarkKey)
+	return frameBytes(magicNumber, 1, res)
+}
+
+func BenchmarkFrameWriter_Encode(b *testing.B) {
+	w := newFrameWriter()
+	req := newRawRequest("GetServerInformation", api.GetServerInformation{Name: api.ServerInformationNamePlayers})
+	req.AuthToken = "0123456789abcdef0123456789abcdef"
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f, err := w.encode(uint32(i), req, benchmarkKey)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_, _ = io.Discard.Write(f)
+	}
+}
+
+func BenchmarkReadFrame(b *testing.B) {
+	data := benchmarkPlayersResponse(b)
+	r := bytes.NewReader(data)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Reset(data)
+		buf := getBuffer()
+		_, content, err := readFrame(r, defaultMaxFrameSize, *buf)
+		if err != nil {
+			b.Fatal(err)
+		}
+		xor(content, benchmarkKey)
+		*buf = content
+		putBuffer(buf)
+	}
+}
@@ -0,0 +1,51 @@
+package rconv2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func frameBytes(magic, id uint32, content []byte) []byte {
+	b := binary.LittleEndian.AppendUint32(nil, magic)
+	b = binary.LittleEndian.AppendUint32(b, id)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(content)))
+	return append(b, content...)
+}
+
+func FuzzReadFrame(f *testing.F) {
+	f.Add(frameBytes(magicNumber, 1, []byte(`{"statusCode":200}`)))
+	f.Add(frameBytes(magicNumber, 0, nil))
+	f.Add(frameBytes(magicNumber+1, 1, []byte("content")))
+	f.Add(frameBytes(magicNumber, 1, []byte("content"))[:15])
+	f.Add(binary.LittleEndian.AppendUint32(frameBytes(magicNumber, 2, nil)[:8], 0xFFFFFFFF))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		const maxSize = 1024
+		id, content, err := readFrame(bytes.NewReader(data), maxSize, nil)
+		if err != nil {
+			if content != nil {
+				t.Fatalf("content returned together with error %v", err)
+			}
+			return
+		}
+		if len(content) > maxSize {
+			t.Fatalf("content of %d bytes exceeds maximum frame size", len(content))
+		}
+		if !bytes.Equal(data[:headerSize+len(content)], frameBytes(magicNumber, id, content)) {
+			t.Fatalf("decoded frame does not match input")
+		}
+	})
+}
+
+func TestReadFrame_Desync(t *testing.T) {
+	_, _, err := readFrame(bytes.NewReader(frameBytes(magicNumber+1, 1, nil)), defaultMaxFrameSize, nil)
+	if !errors.Is(err, ErrProtocolDesync) {
+		t.Fatalf("expected ErrProtocolDesync, got %v", err)
+	}
+	_, _, err = readFrame(bytes.NewReader(frameBytes(magicNumber, 1, make([]byte, 11))), 10, nil)
+	if !errors.Is(err, ErrProtocolDesync) {
+		t.Fatalf("expected ErrProtocolDesync, got %v", err)
+	}
+}
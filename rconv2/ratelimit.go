@@ -0,0 +1,223 @@
+package rconv2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited matches RateLimitExceeded errors, returned when a command was not sent because the rate limit of its
+// CommandCategory was exceeded.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// CommandCategory groups commands with a similar impact on the server and its players. Each category can be given its
+// own rate limit with RateLimitOptions.
+type CommandCategory string
+
+const (
+	// CommandCategoryRead are commands only reading information from the server, e.g. GetServerInformation.
+	CommandCategoryRead CommandCategory = "read"
+	// CommandCategoryMessage are commands showing a message to players, e.g. MessagePlayer.
+	CommandCategoryMessage CommandCategory = "message"
+	// CommandCategoryModeration are commands acting on players, e.g. KickPlayer or TemporaryBanPlayer.
+	CommandCategoryModeration CommandCategory = "moderation"
+	// CommandCategoryOther are all other commands, mostly changing the configuration of the server.
+	CommandCategoryOther CommandCategory = "other"
+)
+
+var (
+	messageCommands    = []string{"MessagePlayer", "SendServerMessage", "ServerBroadcast"}
+	moderationCommands = []string{
+		"DisbandPlatoon", "ForceTeamSwitch", "KickPlayer", "PermanentBanPlayer", "PunishPlayer", "RemovePermanentBan",
+		"RemovePlayerFromPlatoon", "RemoveTemporaryBan", "TemporaryBanPlayer",
+	}
+)
+
+// CategoryOf returns the CommandCategory of the command with the given name.
+func CategoryOf(command string) CommandCategory {
+	switch {
+	case strings.HasPrefix(command, "Get"):
+		return CommandCategoryRead
+	case slices.Contains(messageCommands, command):
+		return CommandCategoryMessage
+	case slices.Contains(moderationCommands, command):
+		return CommandCategoryModeration
+	}
+	return CommandCategoryOther
+}
+
+// IsRetryable reports whether the command with the given name can be sent again safely, after it failed because of a
+// broken connection. Only reads (see CommandCategoryRead) are retryable, all other commands change the state of the
+// server or its players and might have been executed already, even though no response was received.
+func IsRetryable(command string) bool {
+	return CategoryOf(command) == CommandCategoryRead
+}
+
+// RateLimit is the budget of a token bucket: Burst commands can be sent at once, after which the bucket refills at
+// Rate commands per second.
+type RateLimit struct {
+	// Rate is the number of commands per second, which can be sent on average. Must be greater than 0.
+	Rate float64
+	// Burst is the maximum number of commands, which can be sent at once. Defaults to 1.
+	Burst int
+}
+
+// RateLimitOptions configure client-side rate limits of the commands sent by all Connections of a ConnectionPool.
+// Categories without a RateLimit are not limited.
+type RateLimitOptions struct {
+	Read       *RateLimit
+	Message    *RateLimit
+	Moderation *RateLimit
+	Other      *RateLimit
+	// FailFast returns a RateLimitExceeded error immediately, if a command exceeds the rate limit of its category.
+	// Otherwise, the command waits until it is allowed to be sent, or until its context.Context is done.
+	FailFast bool
+}
+
+// RateLimitExceeded is returned when a command was not sent to the server, because it exceeded the rate limit of its
+// CommandCategory. It matches ErrRateLimited with errors.Is. If the command was waiting for the rate limit when its
+// context.Context was done, the error also wraps the error of the context.Context.
+type RateLimitExceeded struct {
+	command    string
+	category   CommandCategory
+	retryAfter time.Duration
+	err        error
+}
+
+// Command returns the name of the command, which was not sent.
+func (r RateLimitExceeded) Command() string {
+	return r.command
+}
+
+// Category returns the CommandCategory of the command, which rate limit was exceeded.
+func (r RateLimitExceeded) Category() CommandCategory {
+	return r.category
+}
+
+// RetryAfter returns the time after which the command would have been allowed to be sent.
+func (r RateLimitExceeded) RetryAfter() time.Duration {
+	return r.retryAfter
+}
+
+func (r RateLimitExceeded) Error() string {
+	if r.err != nil {
+		return fmt.Sprintf("rate limit of %s commands exceeded for %s: %s", r.category, r.command, r.err)
+	}
+	return fmt.Sprintf("rate limit of %s commands exceeded for %s, retry after %s", r.category, r.command, r.retryAfter)
+}
+
+func (r RateLimitExceeded) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+func (r RateLimitExceeded) Unwrap() error {
+	return r.err
+}
+
+type rateLimiter struct {
+	buckets  map[CommandCategory]*bucket
+	failFast bool
+}
+
+func newRateLimiter(opts RateLimitOptions) (*rateLimiter, error) {
+	l := &rateLimiter{
+		buckets:  map[CommandCategory]*bucket{},
+		failFast: opts.FailFast,
+	}
+	for c, r := range map[CommandCategory]*RateLimit{
+		CommandCategoryRead:       opts.Read,
+		CommandCategoryMessage:    opts.Message,
+		CommandCategoryModeration: opts.Moderation,
+		CommandCategoryOther:      opts.Other,
+	} {
+		if r == nil {
+			continue
+		}
+		if r.Rate <= 0 || r.Burst < 0 {
+			return nil, fmt.Errorf("the rate limit of %s commands must have a Rate greater than 0 and a Burst not lower than 0", c)
+		}
+		l.buckets[c] = newBucket(*r, time.Now())
+	}
+	return l, nil
+}
+
+// intercept is a CommandInterceptor delaying or rejecting commands exceeding the rate limit of their category.
+func (l *rateLimiter) intercept(ctx context.Context, call *CommandCall, next CommandInvoker) (*CommandResult, error) {
+	c := CategoryOf(call.Name)
+	b, ok := l.buckets[c]
+	if !ok {
+		return next(ctx, call)
+	}
+	wait, ok := b.reserve(time.Now(), !l.failFast)
+	if !ok {
+		return nil, RateLimitExceeded{command: call.Name, category: c, retryAfter: wait}
+	}
+	if wait > 0 {
+		if d, ok := ctx.Deadline(); ok && time.Until(d) < wait {
+			b.cancel()
+			return nil, RateLimitExceeded{command: call.Name, category: c, retryAfter: wait, err: context.DeadlineExceeded}
+		}
+		t := time.NewTimer(wait)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			b.cancel()
+			return nil, RateLimitExceeded{command: call.Name, category: c, retryAfter: wait, err: ctx.Err()}
+		}
+	}
+	return next(ctx, call)
+}
+
+// bucket is a token bucket, which may be in debt with tokens reserved by commands waiting to be sent.
+type bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(r RateLimit, now time.Time) *bucket {
+	burst := math.Max(1, float64(r.Burst))
+	return &bucket{
+		rate:   r.Rate,
+		burst:  burst,
+		tokens: burst,
+		last:   now,
+	}
+}
+
+// reserve takes a token from the bucket and returns the time to wait until the token is available. If wait is false and
+// no token is available right now, no token is taken and false is returned together with the time until a token would
+// be available.
+func (b *bucket) reserve(now time.Time, wait bool) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if now.After(b.last) {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	d := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	if !wait {
+		return d, false
+	}
+	b.tokens--
+	return d, true
+}
+
+// cancel returns a token taken with reserve, which was not used.
+func (b *bucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
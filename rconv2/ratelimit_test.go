@@ -0,0 +1,122 @@
+package rconv2_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/floriansw/go-hll-rcon/rconv2"
+	"github.com/floriansw/go-hll-rcon/rconv2/rconv2test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rate limiting", func() {
+	var s *rconv2test.Server
+	var ctx context.Context
+
+	BeforeEach(func() {
+		var err error
+		s, err = rconv2test.NewServer(password)
+		Expect(err).ToNot(HaveOccurred())
+		s.Respond("MessagePlayer", nil)
+		s.Respond("KickPlayer", nil)
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		Expect(s.Close()).To(Succeed())
+	})
+
+	It("categorizes commands", func() {
+		Expect(rconv2.CategoryOf("GetServerInformation")).To(Equal(rconv2.CommandCategoryRead))
+		Expect(rconv2.CategoryOf("MessagePlayer")).To(Equal(rconv2.CommandCategoryMessage))
+		Expect(rconv2.CategoryOf("TemporaryBanPlayer")).To(Equal(rconv2.CommandCategoryModeration))
+		Expect(rconv2.CategoryOf("SetVipSlotCount")).To(Equal(rconv2.CommandCategoryOther))
+	})
+
+	It("only retries reads", func() {
+		Expect(rconv2.IsRetryable("GetServerInformation")).To(BeTrue())
+		Expect(rconv2.IsRetryable("KickPlayer")).To(BeFalse())
+		Expect(rconv2.IsRetryable("SetVipSlotCount")).To(BeFalse())
+	})
+
+	It("fails fast when the budget of a category is exhausted", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{
+			RateLimit: &rconv2.RateLimitOptions{
+				Message:  &rconv2.RateLimit{Rate: 0.1, Burst: 2},
+				FailFast: true,
+			},
+		})
+		defer p.Shutdown(context.Background())
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		defer p.Return(c, nil)
+
+		Expect(c.MessagePlayer(ctx, "1", "first")).To(Succeed())
+		Expect(c.MessagePlayer(ctx, "1", "second")).To(Succeed())
+		err = c.MessagePlayer(ctx, "1", "third")
+
+		Expect(errors.Is(err, rconv2.ErrRateLimited)).To(BeTrue())
+		var rerr rconv2.RateLimitExceeded
+		Expect(errors.As(err, &rerr)).To(BeTrue())
+		Expect(rerr.Category()).To(Equal(rconv2.CommandCategoryMessage))
+		Expect(rerr.RetryAfter()).To(BeNumerically(">", 9*time.Second))
+		Expect(rconv2.IsBrokenHllConnection(err)).To(BeFalse())
+		Expect(s.RequestsFor("MessagePlayer")).To(HaveLen(2))
+		// other categories have their own budget
+		Expect(c.KickPlayer(ctx, "1", "reason")).To(Succeed())
+	})
+
+	It("delays commands until the budget allows them", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{
+			RateLimit: &rconv2.RateLimitOptions{
+				Message: &rconv2.RateLimit{Rate: 20, Burst: 1},
+			},
+		})
+		defer p.Shutdown(context.Background())
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		defer p.Return(c, nil)
+
+		started := time.Now()
+		for i := 0; i < 5; i++ {
+			Expect(c.MessagePlayer(ctx, "1", "message")).To(Succeed())
+		}
+
+		Expect(time.Since(started)).To(BeNumerically(">=", 190*time.Millisecond))
+		Expect(s.RequestsFor("MessagePlayer")).To(HaveLen(5))
+	})
+
+	It("does not wait beyond the deadline of the command", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{
+			RateLimit: &rconv2.RateLimitOptions{
+				Message: &rconv2.RateLimit{Rate: 0.1},
+			},
+		})
+		defer p.Shutdown(context.Background())
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		defer p.Return(c, nil)
+		Expect(c.MessagePlayer(ctx, "1", "first")).To(Succeed())
+
+		tctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		started := time.Now()
+		err = c.MessagePlayer(tctx, "1", "second")
+
+		Expect(errors.Is(err, rconv2.ErrRateLimited)).To(BeTrue())
+		Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		Expect(time.Since(started)).To(BeNumerically("<", 100*time.Millisecond))
+	})
+
+	It("rejects invalid rate limits", func() {
+		_, err := rconv2.NewConnectionPool(rconv2.ConnectionPoolOptions{
+			Hostname:  s.Host(),
+			Port:      s.Port(),
+			RateLimit: &rconv2.RateLimitOptions{Read: &rconv2.RateLimit{}},
+		})
+
+		Expect(err).To(HaveOccurred())
+	})
+})
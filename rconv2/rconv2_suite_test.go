@@ -0,0 +1,12 @@
+package rconv2_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestRconV2(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "RConV2 Suite")
+}
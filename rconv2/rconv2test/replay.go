@@ -0,0 +1,74 @@
+package rconv2test
+
+import (
+	"io"
+	"sync"
+
+	"github.com/floriansw/go-hll-rcon/rconv2"
+)
+
+// NewReplayServer starts a Server, which answers requests with the responses of a capture recorded by a
+// rconv2.Recorder. The handshake (ServerConnect and Login) is not replayed, clients authenticate with the passed
+// password instead.
+//
+// Each request is answered with the first not yet replayed entry of the same command and the same request body. If
+// there is none, the first not yet replayed entry of the same command is used. Once all entries of a command were
+// replayed, the last one is repeated, which allows replaying captures of polling clients.
+func NewReplayServer(password string, capture io.Reader) (*Server, error) {
+	entries, err := rconv2.ReadCapture(capture)
+	if err != nil {
+		return nil, err
+	}
+	s, err := NewServer(password)
+	if err != nil {
+		return nil, err
+	}
+	r := &replay{entries: map[string][]*replayEntry{}}
+	for _, e := range entries {
+		if e.Command == CommandServerConnect || e.Command == CommandLogin || e.Error != "" {
+			continue
+		}
+		if _, ok := r.entries[e.Command]; !ok {
+			s.Handle(e.Command, r.handle)
+		}
+		r.entries[e.Command] = append(r.entries[e.Command], &replayEntry{CaptureEntry: e})
+	}
+	return s, nil
+}
+
+type replay struct {
+	mu      sync.Mutex
+	entries map[string][]*replayEntry
+}
+
+type replayEntry struct {
+	rconv2.CaptureEntry
+	replayed bool
+}
+
+func (r *replay) handle(req Request) Response {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := r.entries[req.Command]
+	e := entries[len(entries)-1]
+	if m := r.next(entries, func(e *replayEntry) bool { return e.Request == req.Body }); m != nil {
+		e = m
+	} else if m = r.next(entries, func(e *replayEntry) bool { return true }); m != nil {
+		e = m
+	}
+	e.replayed = true
+	return Response{
+		StatusCode:    e.StatusCode,
+		StatusMessage: e.StatusMessage,
+		Content:       e.Response,
+	}
+}
+
+func (r *replay) next(entries []*replayEntry, match func(e *replayEntry) bool) *replayEntry {
+	for _, e := range entries {
+		if !e.replayed && match(e) {
+			return e
+		}
+	}
+	return nil
+}
@@ -0,0 +1,416 @@
+// Package rconv2test provides an in-process fake of the RCon v2 endpoint of a Hell Let Loose server. It speaks the same
+// protocol as the game server (message framing, XOR encoding of messages and the ServerConnect/Login handshake), which
+// makes it possible to test code using the rconv2 package without a running game server.
+package rconv2test
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	magicNumber = uint32(0xDE450508)
+
+	CommandServerConnect = "ServerConnect"
+	CommandLogin         = "Login"
+)
+
+// Request is a request the Server received from a client.
+type Request struct {
+	RequestId uint32
+	Command   string
+	AuthToken string
+	// Body is the ContentBody of the request as sent by the client.
+	Body string
+}
+
+// Bind decodes the JSON encoded body of the request into v.
+func (r Request) Bind(v any) error {
+	return json.Unmarshal([]byte(r.Body), v)
+}
+
+// Response describes how the Server answers to a Request.
+type Response struct {
+	// StatusCode is the status code of the response. Defaults to 200, if not set.
+	StatusCode    int
+	StatusMessage string
+	// Content is the contentBody of the response. Strings are sent as is, every other value is JSON encoded.
+	Content any
+	// Delay postpones sending the response. Other requests on the same connection are answered in the meantime.
+	Delay time.Duration
+	// Disconnect closes the connection to the client instead of sending a response.
+	Disconnect bool
+	// Raw, if not nil, is written to the connection as is, instead of a properly framed and encoded response. It can
+	// be used to test how clients handle data violating the protocol.
+	Raw []byte
+}
+
+// HandlerFunc answers a Request the Server received.
+type HandlerFunc func(r Request) Response
+
+// Server is a fake Hell Let Loose RCon v2 server listening on a local TCP port. Handlers for commands can be registered
+// with Handle or Respond. A request for a command without a handler is answered with a 404 status code.
+//
+// The ServerConnect and Login commands are handled by the Server itself, unless a handler is registered for them.
+// Every other command is only answered, if the client sent a valid auth token obtained with Login.
+type Server struct {
+	password string
+	l        net.Listener
+
+	mu       sync.Mutex
+	handlers map[string]HandlerFunc
+	tokens   map[string]bool
+	conns    map[*serverConn]bool
+	requests []Request
+	accepted int
+	wg       sync.WaitGroup
+}
+
+// NewServer starts a Server on a random port of the loopback interface. Clients need to authenticate with the passed
+// password.
+func NewServer(password string) (*Server, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		password: password,
+		l:        l,
+		handlers: map[string]HandlerFunc{},
+		tokens:   map[string]bool{},
+		conns:    map[*serverConn]bool{},
+	}
+	s.wg.Add(1)
+	go s.accept()
+	return s, nil
+}
+
+// Host returns the IP address the Server is listening on.
+func (s *Server) Host() string {
+	return s.l.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port returns the TCP port the Server is listening on.
+func (s *Server) Port() int {
+	return s.l.Addr().(*net.TCPAddr).Port
+}
+
+// Addr returns the address of the Server in the host:port notation.
+func (s *Server) Addr() string {
+	return net.JoinHostPort(s.Host(), strconv.Itoa(s.Port()))
+}
+
+// Handle registers the handler h for the command. A previously registered handler for the same command is replaced.
+func (s *Server) Handle(command string, h HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[command] = h
+}
+
+// Respond registers a handler for the command, which answers each request with a 200 status code and content.
+func (s *Server) Respond(command string, content any) {
+	s.Handle(command, func(r Request) Response {
+		return Response{Content: content}
+	})
+}
+
+// Requests returns all requests received by the Server so far, in the order they were received. Requests of the
+// ServerConnect and Login handshake are included.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request{}, s.requests...)
+}
+
+// RequestsFor returns all requests received by the Server for the command.
+func (s *Server) RequestsFor(command string) []Request {
+	var res []Request
+	for _, r := range s.Requests() {
+		if r.Command == command {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// Accepted returns the number of connections the Server accepted since it was started.
+func (s *Server) Accepted() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.accepted
+}
+
+// OpenConnections returns the number of connections to clients, which are currently open.
+func (s *Server) OpenConnections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+// DisconnectAll closes all currently open connections to clients. The Server continues to accept new connections.
+func (s *Server) DisconnectAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.conns {
+		_ = c.con.Close()
+	}
+}
+
+// InvalidateTokens revokes all auth tokens issued so far, as it happens when the game server restarts. Requests with
+// one of these tokens are answered with a 401 status code.
+func (s *Server) InvalidateTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = map[string]bool{}
+}
+
+// Close stops the Server and closes all open connections.
+func (s *Server) Close() error {
+	err := s.l.Close()
+	s.DisconnectAll()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) accept() {
+	defer s.wg.Done()
+	for {
+		con, err := s.l.Accept()
+		if err != nil {
+			return
+		}
+		c := &serverConn{s: s, con: con, closed: make(chan struct{})}
+		s.mu.Lock()
+		s.accepted++
+		s.conns[c] = true
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go c.serve()
+	}
+}
+
+func (s *Server) handler(command string) (HandlerFunc, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.handlers[command]
+	return h, ok
+}
+
+func (s *Server) record(r Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r)
+}
+
+func (s *Server) validToken(t string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokens[t]
+}
+
+func (s *Server) issueToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	t := hex.EncodeToString(b)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[t] = true
+	return t
+}
+
+type serverConn struct {
+	s   *Server
+	con net.Conn
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	xorKey  []byte
+	wg      sync.WaitGroup
+	// closed is closed once the connection to the client was closed
+	closed chan struct{}
+}
+
+type rawRequest struct {
+	Command   string `json:"Name"`
+	AuthToken string `json:"AuthToken"`
+	Body      any    `json:"ContentBody"`
+	Version   int    `json:"Version"`
+}
+
+type rawResponse struct {
+	StatusCode    int    `json:"statusCode"`
+	StatusMessage string `json:"statusMessage"`
+	Version       int    `json:"version"`
+	Command       string `json:"name"`
+	Content       string `json:"contentBody"`
+}
+
+func (c *serverConn) serve() {
+	defer c.s.wg.Done()
+	defer func() {
+		_ = c.con.Close()
+		close(c.closed)
+		c.wg.Wait()
+		c.s.mu.Lock()
+		delete(c.s.conns, c)
+		c.s.mu.Unlock()
+	}()
+	for {
+		id, data, err := c.read()
+		if err != nil {
+			return
+		}
+		var raw rawRequest
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return
+		}
+		r := Request{
+			RequestId: id,
+			Command:   raw.Command,
+			AuthToken: raw.AuthToken,
+		}
+		if b, ok := raw.Body.(string); ok {
+			r.Body = b
+		} else if raw.Body != nil {
+			d, _ := json.Marshal(raw.Body)
+			r.Body = string(d)
+		}
+		c.s.record(r)
+
+		// the handshake has to be answered in order, as the XOR key is changed by it
+		if r.Command == CommandServerConnect || r.Command == CommandLogin {
+			c.respond(r, c.handle(r))
+			continue
+		}
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.respond(r, c.handle(r))
+		}()
+	}
+}
+
+func (c *serverConn) handle(r Request) Response {
+	h, ok := c.s.handler(r.Command)
+	switch {
+	case r.Command == CommandServerConnect && !ok:
+		key := make([]byte, 32)
+		_, _ = rand.Read(key)
+		return Response{Content: base64.StdEncoding.EncodeToString(key)}
+	case r.Command == CommandLogin && !ok:
+		if r.Body != c.s.password {
+			return Response{StatusCode: 401, StatusMessage: "invalid password"}
+		}
+		return Response{Content: c.s.issueToken()}
+	case r.Command == CommandServerConnect || r.Command == CommandLogin:
+		return h(r)
+	case !c.s.validToken(r.AuthToken):
+		return Response{StatusCode: 401, StatusMessage: "unauthorized"}
+	case !ok:
+		return Response{StatusCode: 404, StatusMessage: fmt.Sprintf("unknown command %s", r.Command)}
+	default:
+		return h(r)
+	}
+}
+
+func (c *serverConn) respond(r Request, res Response) {
+	if res.Delay > 0 {
+		t := time.NewTimer(res.Delay)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-c.closed:
+			return
+		}
+	}
+	if res.Disconnect {
+		_ = c.con.Close()
+		return
+	}
+	if res.Raw != nil {
+		c.writeMu.Lock()
+		defer c.writeMu.Unlock()
+		_, _ = c.con.Write(res.Raw)
+		return
+	}
+	if res.StatusCode == 0 {
+		res.StatusCode = 200
+	}
+	raw := rawResponse{
+		StatusCode:    res.StatusCode,
+		StatusMessage: res.StatusMessage,
+		Version:       2,
+		Command:       r.Command,
+	}
+	if s, ok := res.Content.(string); ok {
+		raw.Content = s
+	} else if res.Content != nil {
+		d, _ := json.Marshal(res.Content)
+		raw.Content = string(d)
+	}
+	d, _ := json.Marshal(raw)
+	_ = c.write(r.RequestId, d)
+
+	if r.Command == CommandServerConnect && res.StatusCode == 200 {
+		if key, err := base64.StdEncoding.DecodeString(raw.Content); err == nil {
+			c.mu.Lock()
+			c.xorKey = key
+			c.mu.Unlock()
+		}
+	}
+	if r.Command == CommandLogin && res.StatusCode == 200 {
+		c.s.mu.Lock()
+		c.s.tokens[raw.Content] = true
+		c.s.mu.Unlock()
+	}
+}
+
+func (c *serverConn) read() (uint32, []byte, error) {
+	var header [3]uint32
+	if err := binary.Read(c.con, binary.LittleEndian, &header); err != nil {
+		return 0, nil, err
+	}
+	if header[0] != magicNumber {
+		return 0, nil, errors.New("magic number does not match")
+	}
+	data := make([]byte, header[2])
+	if _, err := io.ReadFull(c.con, data); err != nil {
+		return 0, nil, err
+	}
+	return header[1], c.xor(data), nil
+}
+
+func (c *serverConn) write(id uint32, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	data = c.xor(data)
+	if err := binary.Write(c.con, binary.LittleEndian, []uint32{magicNumber, id, uint32(len(data))}); err != nil {
+		return err
+	}
+	_, err := c.con.Write(data)
+	return err
+}
+
+func (c *serverConn) xor(src []byte) []byte {
+	c.mu.Lock()
+	key := c.xorKey
+	c.mu.Unlock()
+	if key == nil {
+		return src
+	}
+	msg := make([]byte, len(src))
+	for i, b := range src {
+		msg[i] = b ^ key[i%len(key)]
+	}
+	return msg
+}
@@ -0,0 +1,102 @@
+package rconv2
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+var (
+	defaultReconnectPolicy = ReconnectPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: 250 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		DialTimeout:    5 * time.Second,
+		GiveUpError:    ReconnectTriesExceeded,
+	}
+)
+
+// ReconnectPolicy controls how connections to the server are established, both when a new Connection is opened and
+// when an existing Connection needs to reconnect because the server closed the underlying TCP connection.
+// Zero values are replaced with their respective default.
+type ReconnectPolicy struct {
+	// MaxAttempts is the number of consecutive connection attempts before giving up. A successfully sent command
+	// resets the number of attempts. Defaults to 4.
+	MaxAttempts int
+	// InitialBackoff is the delay before the second attempt. The first attempt is always made immediately.
+	// Defaults to 250ms.
+	InitialBackoff time.Duration
+	// MaxBackoff is the upper limit of the delay between two attempts. Defaults to 10s.
+	MaxBackoff time.Duration
+	// Multiplier is the factor the delay grows with on each further attempt. Defaults to 2.
+	Multiplier float64
+	// Jitter is the fraction of the delay which is randomly added to or subtracted from it, to prevent multiple
+	// connections from reconnecting at the same time. A value of 0.2 results in a delay between 80% and 120% of the
+	// calculated value. Defaults to 0.2, use a negative value to disable jitter.
+	Jitter float64
+	// DialTimeout is the maximum time a single attempt to open the TCP connection may take. Defaults to 5s.
+	DialTimeout time.Duration
+	// GiveUpError is returned once all attempts failed. The returned error also wraps the error of the last attempt.
+	// Defaults to ReconnectTriesExceeded.
+	GiveUpError error
+}
+
+func (r *ReconnectPolicy) withDefaults() ReconnectPolicy {
+	if r == nil {
+		return defaultReconnectPolicy
+	}
+	p := *r
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaultReconnectPolicy.MaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = defaultReconnectPolicy.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaultReconnectPolicy.MaxBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = defaultReconnectPolicy.Multiplier
+	}
+	if p.Jitter == 0 {
+		p.Jitter = defaultReconnectPolicy.Jitter
+	} else if p.Jitter < 0 {
+		p.Jitter = 0
+	}
+	if p.DialTimeout <= 0 {
+		p.DialTimeout = defaultReconnectPolicy.DialTimeout
+	}
+	if p.GiveUpError == nil {
+		p.GiveUpError = defaultReconnectPolicy.GiveUpError
+	}
+	return p
+}
+
+// backoff returns the delay before the attempt with the given number, starting at 0 for the first attempt.
+func (r ReconnectPolicy) backoff(attempt int) time.Duration {
+	if attempt == 0 {
+		return 0
+	}
+	d := float64(r.InitialBackoff) * math.Pow(r.Multiplier, float64(attempt-1))
+	d = math.Min(d, float64(r.MaxBackoff))
+	d += d * r.Jitter * (rand.Float64()*2 - 1)
+	return time.Duration(d)
+}
+
+// sleep waits for the duration d or until the context.Context is done, whichever happens first.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return newCommandAborted(ctx)
+	}
+}
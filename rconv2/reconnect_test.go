@@ -0,0 +1,56 @@
+package rconv2_test
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"time"
+
+	"github.com/floriansw/go-hll-rcon/rconv2"
+	"github.com/floriansw/go-hll-rcon/rconv2/rconv2test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReconnectPolicy", func() {
+	It("backs off between attempts and gives up", func() {
+		s, err := rconv2test.NewServer(password)
+		Expect(err).ToNot(HaveOccurred())
+		// the port is not reachable anymore once the server is closed
+		Expect(s.Close()).To(Succeed())
+		giveUp := errors.New("server unreachable")
+		p := newPool(s, rconv2.ConnectionPoolOptions{
+			ReconnectPolicy: &rconv2.ReconnectPolicy{
+				MaxAttempts:    3,
+				InitialBackoff: 50 * time.Millisecond,
+				Jitter:         -1,
+				GiveUpError:    giveUp,
+			},
+		})
+
+		started := time.Now()
+		_, err = p.Get(context.Background())
+
+		Expect(time.Since(started)).To(BeNumerically(">=", 150*time.Millisecond))
+		Expect(errors.Is(err, giveUp)).To(BeTrue())
+		Expect(errors.Is(err, syscall.ECONNREFUSED)).To(BeTrue())
+	})
+
+	It("stops reconnecting when the context is cancelled", func() {
+		s, err := rconv2test.NewServer(password)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.Close()).To(Succeed())
+		p := newPool(s, rconv2.ConnectionPoolOptions{
+			ReconnectPolicy: &rconv2.ReconnectPolicy{
+				MaxAttempts:    10,
+				InitialBackoff: time.Second,
+			},
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		_, err = p.Get(ctx)
+
+		Expect(errors.Is(err, rconv2.ErrCommandAborted)).To(BeTrue())
+	})
+})
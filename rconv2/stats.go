@@ -0,0 +1,140 @@
+package rconv2
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PoolStats is a snapshot of the usage of a ConnectionPool since it was created, obtained with ConnectionPool.Stats.
+type PoolStats struct {
+	MaxOpenConnections int
+
+	// OpenConnections is the number of open connections, both in use and idle, including connections currently being
+	// opened.
+	OpenConnections int
+	// InUse is the number of connections currently obtained with Get and not returned yet.
+	InUse int
+	// Idle is the number of idle connections in the pool.
+	Idle int
+
+	// Waiting is the number of Get requests currently waiting for a connection.
+	Waiting int
+	// WaitCount is the total number of Get requests, which needed to wait for a connection because
+	// MaxOpenConnections was reached. A high number indicates that MaxOpenConnections is too small.
+	WaitCount int64
+	// WaitDuration is the total time Get requests waited for a connection.
+	WaitDuration time.Duration
+
+	// Opened is the total number of connections opened.
+	Opened int64
+	// Closed is the total number of connections closed, for any reason.
+	Closed int64
+	// ClosedBroken is the number of connections closed because they were returned with an error indicating a broken
+	// connection (see IsBrokenHllConnection).
+	ClosedBroken int64
+	// ClosedMaxIdle is the number of connections closed because MaxIdleConnections was reached when they were
+	// returned to the pool.
+	ClosedMaxIdle int64
+	// ClosedMaxIdleTime is the number of connections closed because of MaxIdleTime.
+	ClosedMaxIdleTime int64
+	// ClosedMaxLifetime is the number of connections closed because of MaxConnectionLifetime.
+	ClosedMaxLifetime int64
+	// ClosedUnhealthy is the number of idle connections closed because the server closed them or because they failed
+	// the health check.
+	ClosedUnhealthy int64
+
+	// Commands are the statistics of the commands sent with connections of the pool, by command name.
+	Commands map[string]CommandStats
+}
+
+// CommandStats are the statistics of a single command.
+type CommandStats struct {
+	// Count is the number of times the command was sent, including failed attempts.
+	Count int64
+	// Errors is the number of times the command failed, either with an error or with a status code other than 200.
+	Errors int64
+	// Duration is the total time the command took, including waiting for rate limits.
+	Duration time.Duration
+}
+
+type closeReason int
+
+const (
+	closeBroken closeReason = iota
+	closeMaxIdle
+	closeMaxIdleTime
+	closeMaxLifetime
+	closeUnhealthy
+	closeShutdown
+)
+
+// poolStats collects the statistics of a ConnectionPool, which are not derived from its state.
+type poolStats struct {
+	waitCount    atomic.Int64
+	waitDuration atomic.Int64
+	opened       atomic.Int64
+	closed       [closeShutdown + 1]atomic.Int64
+
+	mu       sync.Mutex
+	commands map[string]CommandStats
+}
+
+// Stats returns the current statistics of the pool.
+func (p *ConnectionPool) Stats() PoolStats {
+	p.mu.Lock()
+	s := PoolStats{
+		MaxOpenConnections: p.maxOpenCount,
+		OpenConnections:    p.numOpen,
+		InUse:              p.numInUse,
+		Idle:               len(p.idles),
+		Waiting:            len(p.queued),
+	}
+	p.mu.Unlock()
+
+	s.WaitCount = p.stats.waitCount.Load()
+	s.WaitDuration = time.Duration(p.stats.waitDuration.Load())
+	s.Opened = p.stats.opened.Load()
+	for i := range p.stats.closed {
+		s.Closed += p.stats.closed[i].Load()
+	}
+	s.ClosedBroken = p.stats.closed[closeBroken].Load()
+	s.ClosedMaxIdle = p.stats.closed[closeMaxIdle].Load()
+	s.ClosedMaxIdleTime = p.stats.closed[closeMaxIdleTime].Load()
+	s.ClosedMaxLifetime = p.stats.closed[closeMaxLifetime].Load()
+	s.ClosedUnhealthy = p.stats.closed[closeUnhealthy].Load()
+
+	p.stats.mu.Lock()
+	s.Commands = maps.Clone(p.stats.commands)
+	p.stats.mu.Unlock()
+	if s.Commands == nil {
+		s.Commands = map[string]CommandStats{}
+	}
+	return s
+}
+
+func (s *poolStats) waited(d time.Duration) {
+	s.waitCount.Add(1)
+	s.waitDuration.Add(int64(d))
+}
+
+// intercept is a CommandInterceptor counting the commands sent with the connections of the pool.
+func (s *poolStats) intercept(ctx context.Context, call *CommandCall, next CommandInvoker) (*CommandResult, error) {
+	started := time.Now()
+	res, err := next(ctx, call)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.commands == nil {
+		s.commands = map[string]CommandStats{}
+	}
+	cs := s.commands[call.Name]
+	cs.Count++
+	cs.Duration += time.Since(started)
+	if err != nil || res.StatusCode != 200 {
+		cs.Errors++
+	}
+	s.commands[call.Name] = cs
+	return res, err
+}
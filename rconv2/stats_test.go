@@ -0,0 +1,164 @@
+package rconv2_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/floriansw/go-hll-rcon/rconv2"
+	"github.com/floriansw/go-hll-rcon/rconv2/rconv2test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pool statistics", func() {
+	var s *rconv2test.Server
+	var ctx context.Context
+
+	BeforeEach(func() {
+		var err error
+		s, err = rconv2test.NewServer(password)
+		Expect(err).ToNot(HaveOccurred())
+		s.Respond("MessagePlayer", nil)
+		s.Handle("KickPlayer", func(r rconv2test.Request) rconv2test.Response {
+			return rconv2test.Response{StatusCode: 400, StatusMessage: "player not found"}
+		})
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		Expect(s.Close()).To(Succeed())
+	})
+
+	It("counts open, idle and in-use connections", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{})
+		defer p.Shutdown(context.Background())
+		first, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		second, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		p.Return(second, nil)
+
+		st := p.Stats()
+		Expect(st.MaxOpenConnections).To(Equal(10))
+		Expect(st.OpenConnections).To(Equal(2))
+		Expect(st.InUse).To(Equal(1))
+		Expect(st.Idle).To(Equal(1))
+		Expect(st.Opened).To(Equal(int64(2)))
+		Expect(st.Closed).To(Equal(int64(0)))
+		p.Return(first, nil)
+	})
+
+	It("counts closed connections by reason", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{MaxIdleConnections: ptr(1)})
+		defer p.Shutdown(context.Background())
+		first, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		second, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		third, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		p.Return(first, nil)
+		p.Return(second, nil)
+		p.Return(third, rconv2.ErrProtocolDesync)
+
+		st := p.Stats()
+		Expect(st.OpenConnections).To(Equal(1))
+		Expect(st.Opened).To(Equal(int64(3)))
+		Expect(st.Closed).To(Equal(int64(2)))
+		Expect(st.ClosedMaxIdle).To(Equal(int64(1)))
+		Expect(st.ClosedBroken).To(Equal(int64(1)))
+	})
+
+	It("does not count connections being opened as in use", func() {
+		dialing := make(chan struct{}, 1)
+		unblock := make(chan struct{})
+		p := newPool(s, rconv2.ConnectionPoolOptions{
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				dialing <- struct{}{}
+				<-unblock
+				var d net.Dialer
+				return d.DialContext(ctx, network, address)
+			},
+		})
+		defer p.Shutdown(context.Background())
+		opened := make(chan *rconv2.Connection, 1)
+		go func() {
+			defer GinkgoRecover()
+			c, err := p.Get(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			opened <- c
+		}()
+		Eventually(dialing).Should(Receive())
+
+		st := p.Stats()
+		Expect(st.OpenConnections).To(Equal(1))
+		Expect(st.InUse).To(Equal(0))
+
+		close(unblock)
+		var c *rconv2.Connection
+		Eventually(opened).Should(Receive(&c))
+		Expect(p.Stats().InUse).To(Equal(1))
+		p.Return(c, nil)
+		Expect(p.Stats().InUse).To(Equal(0))
+	})
+
+	It("counts connections closed because of their lifetime", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{MaxConnectionLifetime: 50 * time.Millisecond})
+		defer p.Shutdown(context.Background())
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		p.Return(c, nil)
+
+		Eventually(func() int64 { return p.Stats().ClosedMaxLifetime }).Should(Equal(int64(1)))
+		Expect(p.Stats().OpenConnections).To(Equal(0))
+	})
+
+	It("counts waiting requests", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{MaxOpenConnections: ptr(1), MaxIdleConnections: ptr(1)})
+		defer p.Shutdown(context.Background())
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		go func() {
+			defer GinkgoRecover()
+			Eventually(func() int { return p.Stats().Waiting }).Should(Equal(1))
+			time.Sleep(20 * time.Millisecond)
+			p.Return(c, nil)
+		}()
+		n, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		p.Return(n, nil)
+
+		st := p.Stats()
+		Expect(st.Waiting).To(Equal(0))
+		Expect(st.WaitCount).To(Equal(int64(1)))
+		Expect(st.WaitDuration).To(BeNumerically(">=", 20*time.Millisecond))
+	})
+
+	It("counts commands and their errors", func() {
+		p := newPool(s, rconv2.ConnectionPoolOptions{
+			RateLimit: &rconv2.RateLimitOptions{
+				Message:  &rconv2.RateLimit{Rate: 0.1, Burst: 1},
+				FailFast: true,
+			},
+		})
+		defer p.Shutdown(context.Background())
+		c, err := p.Get(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		defer p.Return(c, nil)
+
+		Expect(c.MessagePlayer(ctx, "1", "first")).To(Succeed())
+		Expect(errors.Is(c.MessagePlayer(ctx, "1", "second"), rconv2.ErrRateLimited)).To(BeTrue())
+		Expect(c.KickPlayer(ctx, "1", "reason")).ToNot(Succeed())
+
+		st := p.Stats()
+		Expect(st.Commands).To(HaveLen(2))
+		Expect(st.Commands["MessagePlayer"].Count).To(Equal(int64(2)))
+		Expect(st.Commands["MessagePlayer"].Errors).To(Equal(int64(1)))
+		Expect(st.Commands["KickPlayer"].Count).To(Equal(int64(1)))
+		Expect(st.Commands["KickPlayer"].Errors).To(Equal(int64(1)))
+	})
+})